@@ -0,0 +1,79 @@
+package proberesponder
+
+import (
+	"fmt"
+	"time"
+)
+
+// CheckResult is the result of a dependency check (e.g. by depprober). Along with the
+// structured result, it is also maintained as an entry in the health response.
+type CheckResult struct {
+	// ID is the unique ID of the dependency, and is also the key in the health response
+	ID    string `json:"id"`
+	NotOK bool   `json:"notOK"`
+	// Error is the error returned by the check, if any
+	Error string `json:"error,omitempty"`
+	// AffectedStatuses are the statuses which are affected if the check fails
+	AffectedStatuses []Statuskey   `json:"affectedStatuses,omitempty"`
+	Latency          time.Duration `json:"latency"`
+	CheckedAt        time.Time     `json:"checkedAt"`
+}
+
+// Affects returns true if the check affects the status
+func (cr CheckResult) Affects(status Statuskey) bool {
+	for _, afStatus := range cr.AffectedStatuses {
+		if afStatus == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCheckResult records the result of a dependency check, and adds it to the health response
+// with cr.ID as the key.
+func (pr *ProbeResponder) SetCheckResult(cr CheckResult) {
+	if pr == nil {
+		return
+	}
+
+	hs := HealthOK
+	if cr.NotOK {
+		hs = HealthNotOK
+	}
+	cr.AffectedStatuses = append([]Statuskey(nil), cr.AffectedStatuses...)
+
+	pr.locker.Lock()
+	pr.checks[cr.ID] = cr
+	pr.appendHealthRespWithoutLock(
+		cr.ID,
+		fmt.Sprintf("%s: %s", hs, cr.CheckedAt.Format(time.RFC3339)),
+	)
+	pr.locker.Unlock()
+
+	pr.changed()
+}
+
+// CheckResults returns the latest results of all the dependency checks, in the order they
+// were first added
+func (pr *ProbeResponder) CheckResults() []CheckResult {
+	if pr == nil {
+		return nil
+	}
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	return pr.checkResultsWithoutLock()
+}
+
+func (pr *ProbeResponder) checkResultsWithoutLock() []CheckResult {
+	results := make([]CheckResult, 0, len(pr.checks))
+	for _, key := range pr.keys {
+		cr, ok := pr.checks[key]
+		if !ok {
+			continue
+		}
+		cr.AffectedStatuses = append([]Statuskey(nil), cr.AffectedStatuses...)
+		results = append(results, cr)
+	}
+	return results
+}
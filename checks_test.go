@@ -0,0 +1,62 @@
+package proberesponder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeResponder_SetCheckResult(tt *testing.T) {
+	tt.Run("results & payload", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		checkedAt := time.Date(2025, 1, 9, 17, 45, 24, 0, time.UTC)
+		pRes.SetCheckResult(CheckResult{
+			ID:               "mydb",
+			NotOK:            true,
+			Error:            "connection refused",
+			AffectedStatuses: []Statuskey{StatusReady},
+			Latency:          time.Millisecond * 5,
+			CheckedAt:        checkedAt,
+		})
+		pRes.SetCheckResult(CheckResult{ID: "cache", CheckedAt: checkedAt})
+
+		asserter.Equal("NOT OK: 2025-01-09T17:45:24Z", pRes.HealthResponse()["mydb"])
+		asserter.Equal("OK: 2025-01-09T17:45:24Z", pRes.HealthResponse()["cache"])
+
+		results := pRes.CheckResults()
+		asserter.Len(results, 2)
+		asserter.Equal("mydb", results[0].ID)
+		asserter.Equal("connection refused", results[0].Error)
+		asserter.True(results[0].Affects(StatusReady))
+		asserter.False(results[0].Affects(StatusLive))
+		asserter.Equal("cache", results[1].ID)
+
+		pRes.SetCheckResult(CheckResult{ID: "mydb", CheckedAt: checkedAt})
+		results = pRes.CheckResults()
+		asserter.Len(results, 2)
+		asserter.False(results[0].NotOK)
+		asserter.Len(pRes.Snapshot().Checks, 2)
+	})
+
+	tt.Run("uninitialized", func(t *testing.T) {
+		asserter := assert.New(t)
+		var pRes *ProbeResponder
+		pRes.SetCheckResult(CheckResult{ID: "mydb"})
+		asserter.Nil(pRes.CheckResults())
+		asserter.Nil(pRes.Statuses())
+	})
+}
+
+func TestProbeResponder_StatusSnapshots(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := New()
+	pRes.SetStatus(StatusReady, false, "caches warmed up")
+
+	statuses := pRes.Statuses()
+	asserter.Len(statuses, 3)
+	asserter.False(statuses[StatusReady].NotOK)
+	asserter.Equal("caches warmed up", statuses[StatusReady].Reason)
+	asserter.True(statuses[StatusLive].NotOK)
+}
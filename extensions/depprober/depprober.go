@@ -5,17 +5,27 @@ package depprober
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
 )
 
 var (
 	// ensure Probe implements Prober
 	_ = Prober(&Probe{})
+
+	pkgLogger = atomic.Pointer[logging.Logger]{}
 )
 
+// SetLogger sets the default logger used to log the results of probes. Logging is disabled
+// by default. The logger can be set per Start instead, with StartWithLogger.
+func SetLogger(lg *logging.Logger) {
+	pkgLogger.Store(lg)
+}
+
 type Prober interface {
 	// ServiceID unique ID for the dependency
 	ServiceID() string
@@ -62,6 +72,10 @@ type DependencyStatus struct {
 	Status           string
 	AffectedStatuses []proberesponder.Statuskey
 	AsOf             time.Time
+	// Latency is the time taken by the check
+	Latency time.Duration
+	// Err is the error returned by the check, if any
+	Err error
 }
 
 func ProbeDependencies(
@@ -82,9 +96,11 @@ func ProbeDependencies(
 				Status:           healthOK,
 				AffectedStatuses: pinger.AffectsStatuses(),
 			}
-			err := pinger.Check(ctx)
+			start := time.Now()
+			hc.Err = pinger.Check(ctx)
 			hc.AsOf = time.Now()
-			if err != nil {
+			hc.Latency = hc.AsOf.Sub(start)
+			if hc.Err != nil {
 				hc.Status = healthNotOK
 			}
 			statuses <- hc
@@ -111,6 +127,27 @@ func Start(
 	delay time.Duration,
 	pstatus *proberesponder.ProbeResponder,
 	pingers ...Prober,
+) Stopper {
+	return start(delay, pstatus, pkgLogger.Load, pingers...)
+}
+
+// StartWithLogger is same as Start, but logs the results of probes using lg instead of the
+// logger set using SetLogger. A nil logger disables logging.
+func StartWithLogger(
+	lg *logging.Logger,
+	delay time.Duration,
+	pstatus *proberesponder.ProbeResponder,
+	pingers ...Prober,
+) Stopper {
+	return start(delay, pstatus, func() *logging.Logger { return lg }, pingers...)
+}
+
+// start probes the dependencies every delay, the logger is read on every probe
+func start(
+	delay time.Duration,
+	pstatus *proberesponder.ProbeResponder,
+	logger func() *logging.Logger,
+	pingers ...Prober,
 ) Stopper {
 	if len(pingers) == 0 {
 		return nil
@@ -124,26 +161,38 @@ func Start(
 	*/
 	tick := time.NewTicker(delay)
 	go func() {
-        probe(delay, pstatus, pingers...)
+		probeWithLogger(logger(), delay, pstatus, pingers...)
 		for range tick.C {
-			probe(delay, pstatus, pingers...)
+			probeWithLogger(logger(), delay, pstatus, pingers...)
 		}
 	}()
 	return tick
 }
 
 func probe(delay time.Duration, pstatus *proberesponder.ProbeResponder, pingers ...Prober) {
+	probeWithLogger(pkgLogger.Load(), delay, pstatus, pingers...)
+}
+
+func probeWithLogger(lg *logging.Logger, delay time.Duration, pstatus *proberesponder.ProbeResponder, pingers ...Prober) {
 	startupOK := true
 	readyOK := true
 	liveOK := true
 
 	for _, hc := range ProbeDependencies(delay, pingers...) {
-		pstatus.AppendHealthResponse(
-			hc.ServiceID,
-			fmt.Sprintf("%s: %s", hc.Status, hc.AsOf.Format(time.RFC3339)),
-		)
-
 		ok := proberesponder.IsHealthOK(hc.Status)
+		cr := proberesponder.CheckResult{
+			ID:               hc.ServiceID,
+			NotOK:            !ok,
+			AffectedStatuses: hc.AffectedStatuses,
+			Latency:          hc.Latency,
+			CheckedAt:        hc.AsOf,
+		}
+		if hc.Err != nil {
+			cr.Error = hc.Err.Error()
+		}
+		pstatus.SetCheckResult(cr)
+
+		logResult(lg, hc, ok)
 		for _, afStatus := range hc.AffectedStatuses {
 			switch afStatus {
 			case proberesponder.StatusStartup:
@@ -160,3 +209,27 @@ func probe(delay time.Duration, pstatus *proberesponder.ProbeResponder, pingers
 	pstatus.SetNotReady(!readyOK)
 	pstatus.SetNotLive(!liveOK)
 }
+
+func logResult(lg *logging.Logger, hc DependencyStatus, ok bool) {
+	if lg == nil {
+		return
+	}
+
+	affected := make([]string, 0, len(hc.AffectedStatuses))
+	for _, status := range hc.AffectedStatuses {
+		affected = append(affected, status.String())
+	}
+	attrs := []slog.Attr{
+		slog.String("serviceID", hc.ServiceID),
+		slog.Duration("latency", hc.Latency),
+		slog.Any("affectedStatuses", affected),
+	}
+
+	if ok {
+		lg.Log(context.Background(), logging.EventProbeSuccess, "dependency probe succeeded", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.String("error", hc.Err.Error()))
+	lg.Log(context.Background(), logging.EventProbeFailure, "dependency probe failed", attrs...)
+}
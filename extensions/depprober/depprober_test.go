@@ -4,12 +4,17 @@
 package depprober
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -218,3 +223,108 @@ func TestProber(tt *testing.T) {
 		asserter.Equal(expectedStatuses, pb.AffectsStatuses())
 	})
 }
+
+func TestProbeDependencies(t *testing.T) {
+	asserter := assert.New(t)
+	checkErr := errors.New("service down")
+	statuses := ProbeDependencies(
+		time.Second,
+		&Probe{
+			ID: "slow_service",
+			Checker: CheckerFunc(func(ctx context.Context) error {
+				time.Sleep(time.Millisecond * 10)
+				return nil
+			}),
+		},
+		&DummyPinger{serviceID: "failing_service", err: checkErr},
+	)
+
+	asserter.Len(statuses, 2)
+	for _, status := range statuses {
+		switch status.ServiceID {
+		case "slow_service":
+			asserter.NoError(status.Err)
+			asserter.GreaterOrEqual(status.Latency, time.Millisecond*10)
+		case "failing_service":
+			asserter.ErrorIs(status.Err, checkErr)
+			asserter.Equal(proberesponder.HealthNotOK.String(), status.Status)
+		}
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	asserter := assert.New(t)
+	buff := bytes.NewBuffer(nil)
+	SetLogger(logging.New(slog.New(slog.NewTextHandler(buff, nil)), nil))
+	defer SetLogger(nil)
+
+	probe(time.Second, newProbeRespWithAllOK(),
+		&DummyPinger{serviceID: "healthy_service"},
+		&DummyPinger{serviceID: "failing_service", err: errors.New("connection refused")},
+	)
+
+	logs := buff.String()
+	// probe success is logged at debug level by default
+	asserter.NotContains(logs, "healthy_service")
+	asserter.Contains(logs, "serviceID=failing_service")
+	asserter.Contains(logs, `error="connection refused"`)
+	asserter.Contains(logs, "latency=")
+}
+
+// lockedBuffer is a buffer safe for concurrent use, for logs written by the prober goroutine
+type lockedBuffer struct {
+	locker sync.Mutex
+	buff   bytes.Buffer
+}
+
+func (lb *lockedBuffer) Write(p []byte) (int, error) {
+	lb.locker.Lock()
+	defer lb.locker.Unlock()
+	return lb.buff.Write(p)
+}
+
+func (lb *lockedBuffer) String() string {
+	lb.locker.Lock()
+	defer lb.locker.Unlock()
+	return lb.buff.String()
+}
+
+func TestStartWithLogger(t *testing.T) {
+	asserter := assert.New(t)
+	global := &lockedBuffer{}
+	SetLogger(logging.New(slog.New(slog.NewTextHandler(global, nil)), nil))
+	defer SetLogger(nil)
+
+	buff := &lockedBuffer{}
+	stopper := StartWithLogger(
+		logging.New(slog.New(slog.NewTextHandler(buff, nil)), nil),
+		time.Millisecond*10,
+		newProbeRespWithAllOK(),
+		&DummyPinger{serviceID: "failing_service", err: errors.New("connection refused")},
+	)
+	defer stopper.Stop()
+
+	asserter.Eventually(func() bool {
+		return strings.Contains(buff.String(), "serviceID=failing_service")
+	}, time.Second, time.Millisecond*5)
+	asserter.Empty(global.String())
+}
+
+func TestCheckResults(t *testing.T) {
+	asserter := assert.New(t)
+	pResp := newProbeRespWithAllOK()
+	probe(time.Second, pResp, &DummyPinger{
+		serviceID:      "failing_service",
+		affectedStatus: []proberesponder.Statuskey{proberesponder.StatusReady},
+		err:            errors.New("connection refused"),
+	})
+
+	results := pResp.CheckResults()
+	asserter.Len(results, 1)
+	asserter.Equal("failing_service", results[0].ID)
+	asserter.True(results[0].NotOK)
+	asserter.Equal("connection refused", results[0].Error)
+	asserter.True(results[0].Affects(proberesponder.StatusReady))
+	asserter.False(results[0].CheckedAt.IsZero())
+	asserter.True(pResp.NotReady())
+}
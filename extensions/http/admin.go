@@ -0,0 +1,201 @@
+package http
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+)
+
+const (
+	HTTPPathAdminStartup = "/-/admin/startup"
+	HTTPPathAdminReady   = "/-/admin/ready"
+	HTTPPathAdminLive    = "/-/admin/live"
+
+	queryParamValue  = "value"
+	queryParamReason = "reason"
+	queryParamFor    = "for"
+)
+
+// adminOverrides keeps track of the holds set by admin requests per status, so that a hold is
+// released only by the timer or the admin request which replaces it
+type adminOverrides struct {
+	locker *sync.Mutex
+	holds  map[proberesponder.Statuskey]*adminHold
+}
+
+type adminHold struct {
+	release func()
+	// timer releases the hold after the duration, nil if it is not timed
+	timer *time.Timer
+}
+
+func (ah *adminHold) stop() {
+	if ah.timer != nil {
+		ah.timer.Stop()
+	}
+	ah.release()
+}
+
+// AdminHandlers returns the handlers to set the probe statuses over HTTP, e.g.
+//
+//	curl -X POST -H 'Authorization: Bearer <token>' 'localhost:2000/-/admin/ready?value=false&reason=draining'
+//
+// The query parameter "value" is the status to be set, i.e. false holds it as NOT OK (see
+// ProbeResponder.Hold), so that it's not set as OK again by the dependency probes, and "reason"
+// is the optional reason for it. If the query parameter "for" is set to a duration (e.g. 5m),
+// the hold is released after the duration. true releases the hold, and sets the status as OK,
+// it remains NOT OK though if held otherwise (e.g. shutting down).
+//
+// All requests must be authorized by auth, and the actor is logged. If auth is nil, all
+// requests are rejected. The handlers are not registered by default, they can be added to
+// the server using WithAdmin.
+func AdminHandlers(pres *proberesponder.ProbeResponder, auth Authorizer, opts ...HandlerOption) []Handler {
+	hcfg := newHandlerConfig(opts...)
+	overrides := &adminOverrides{
+		locker: &sync.Mutex{},
+		holds:  map[proberesponder.Statuskey]*adminHold{},
+	}
+
+	return []Handler{
+		{Method: http.MethodPost, Path: HTTPPathAdminStartup, Handler: overrides.handler(hcfg, pres, auth, proberesponder.StatusStartup)},
+		{Method: http.MethodPost, Path: HTTPPathAdminReady, Handler: overrides.handler(hcfg, pres, auth, proberesponder.StatusReady)},
+		{Method: http.MethodPost, Path: HTTPPathAdminLive, Handler: overrides.handler(hcfg, pres, auth, proberesponder.StatusLive)},
+	}
+}
+
+func (ao *adminOverrides) handler(
+	hcfg *handlerConfig,
+	pres *proberesponder.ProbeResponder,
+	auth Authorizer,
+	status proberesponder.Statuskey,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set(httpHeaderAllow, http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		actor, authorized := "", false
+		if auth != nil {
+			actor, authorized = auth(r)
+		}
+		if !authorized {
+			hcfg.logger.log().Log(
+				r.Context(),
+				logging.EventAdminDenied,
+				"unauthorized admin request",
+				slog.String("path", r.URL.Path),
+				slog.String("remoteAddr", r.RemoteAddr),
+			)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+		value, err := strconv.ParseBool(query.Get(queryParamValue))
+		if err != nil {
+			hcfg.respondError(w, r, fmt.Errorf("invalid %s: %q", queryParamValue, query.Get(queryParamValue)))
+			return
+		}
+
+		var duration time.Duration
+		if dur := query.Get(queryParamFor); dur != "" {
+			duration, err = time.ParseDuration(dur)
+			if err != nil || duration <= 0 {
+				hcfg.respondError(w, r, fmt.Errorf("invalid %s: %q", queryParamFor, dur))
+				return
+			}
+			if value {
+				hcfg.respondError(w, r, fmt.Errorf("%s is supported only with %s=false", queryParamFor, queryParamValue))
+				return
+			}
+		}
+
+		reason := query.Get(queryParamReason)
+		ao.set(pres, status, !value, reason, duration)
+
+		attrs := []slog.Attr{
+			slog.String("actor", actor),
+			slog.String("status", status.String()),
+			slog.Bool("notOK", !value),
+			slog.String("reason", reason),
+		}
+		if duration > 0 {
+			attrs = append(attrs, slog.Duration("for", duration))
+		}
+		hcfg.logger.log().Log(r.Context(), logging.EventAdminOverride, "probe status set by admin", attrs...)
+
+		w.Header().Add(httpHeaderContentType, httpHeaderContentTypePlain)
+		w.WriteHeader(http.StatusOK)
+		hcfg.write(w, r, []byte(fmt.Sprintf("%s: %s", status, pres.Statuses()[status])))
+	}
+}
+
+// set holds the status as NOT OK, and releases it after the duration if greater than 0. If
+// notOK is false, it sets the status as OK instead. Any previous hold of the status by an admin
+// request is released.
+func (ao *adminOverrides) set(
+	pres *proberesponder.ProbeResponder,
+	status proberesponder.Statuskey,
+	notOK bool,
+	reason string,
+	duration time.Duration,
+) {
+	ao.locker.Lock()
+	defer ao.locker.Unlock()
+
+	previous := ao.holds[status]
+	delete(ao.holds, status)
+
+	if !notOK {
+		// set before releasing, so that the status is not reverted to a stale value
+		pres.SetStatus(status, false, reason)
+		if previous != nil {
+			previous.stop()
+		}
+		return
+	}
+
+	// held before releasing the previous, so that the status is never OK in between
+	ah := &adminHold{release: pres.Hold(status, reason)}
+	ao.holds[status] = ah
+	if previous != nil {
+		previous.stop()
+	}
+
+	if duration <= 0 {
+		return
+	}
+	ah.timer = time.AfterFunc(duration, func() {
+		ao.locker.Lock()
+		defer ao.locker.Unlock()
+
+		if ao.holds[status] != ah {
+			return
+		}
+		delete(ao.holds, status)
+		ah.release()
+	})
+}
+
+// respondError responds with 400 Bad Request, and the error in plain text
+func (hcfg *handlerConfig) respondError(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Add(httpHeaderContentType, httpHeaderContentTypePlain)
+	w.WriteHeader(http.StatusBadRequest)
+	hcfg.write(w, r, []byte(err.Error()))
+}
+
+// WithAdmin registers the admin handlers, authorized by auth. See AdminHandlers.
+func WithAdmin(auth Authorizer) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.admin = auth
+		scfg.adminEnabled = true
+	}
+}
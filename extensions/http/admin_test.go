@@ -0,0 +1,240 @@
+package http
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/depprober"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func adminReq(target, token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, nil)
+	if token != "" {
+		r.Header.Set(httpHeaderAuthorization, "Bearer "+token)
+	}
+	return r
+}
+
+func adminHandler(handlers []Handler, path string) http.HandlerFunc {
+	for _, h := range handlers {
+		if h.Path == path {
+			return h.Handler
+		}
+	}
+	return nil
+}
+
+func TestAdminHandlers(tt *testing.T) {
+	buff := bytes.NewBuffer(nil)
+	pRes := proberesponder.New()
+	pRes.SetNotReady(false)
+	handlers := AdminHandlers(
+		pRes,
+		BearerToken("alice", "s3cr3t"),
+		WithLogger(logging.New(slog.New(slog.NewTextHandler(buff, nil)), nil)),
+	)
+	ready := adminHandler(handlers, HTTPPathAdminReady)
+
+	tt.Run("unauthorized", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=false", "wrong"))
+		asserter.Equal(http.StatusUnauthorized, w.Result().StatusCode)
+		asserter.False(pRes.NotReady())
+		asserter.Contains(buff.String(), logging.EventAdminDenied.String())
+	})
+
+	tt.Run("method not allowed", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, HTTPPathAdminReady+"?value=false", nil)
+		r.Header.Set(httpHeaderAuthorization, "Bearer s3cr3t")
+		ready(w, r)
+		asserter.Equal(http.StatusMethodNotAllowed, w.Result().StatusCode)
+		asserter.Equal(http.MethodPost, w.Header().Get(httpHeaderAllow))
+		asserter.False(pRes.NotReady())
+	})
+
+	tt.Run("invalid value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=maybe", "s3cr3t"))
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	tt.Run("invalid duration", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=false&for=-1s", "s3cr3t"))
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.False(t, pRes.NotReady())
+	})
+
+	tt.Run("set not ready", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=false&reason=draining", "s3cr3t"))
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Equal("ready: NOT OK (draining)", w.Body.String())
+		asserter.True(pRes.NotReady())
+		asserter.Equal("draining", pRes.Reason(proberesponder.StatusReady))
+		asserter.Contains(buff.String(), "actor=alice")
+		asserter.Contains(buff.String(), logging.EventAdminOverride.String())
+	})
+
+	tt.Run("set ready", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=true", "s3cr3t"))
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.False(t, pRes.NotReady())
+	})
+
+	tt.Run("live & startup", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		adminHandler(handlers, HTTPPathAdminLive)(w, adminReq(HTTPPathAdminLive+"?value=true", "s3cr3t"))
+		asserter.False(pRes.NotLive())
+
+		w = httptest.NewRecorder()
+		adminHandler(handlers, HTTPPathAdminStartup)(w, adminReq(HTTPPathAdminStartup+"?value=true", "s3cr3t"))
+		asserter.False(pRes.NotStarted())
+	})
+}
+
+func TestAdminHandlers_TimedOverride(tt *testing.T) {
+	SetLogger(nil)
+	defer SetLogger(logging.New(nil, nil))
+
+	tt.Run("reverted", func(t *testing.T) {
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+		ready := adminHandler(AdminHandlers(pRes, BearerToken("alice", "s3cr3t")), HTTPPathAdminReady)
+
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=false&for=20ms", "s3cr3t"))
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.True(t, pRes.NotReady())
+		assert.Eventually(t, func() bool {
+			return !pRes.Statuses()[proberesponder.StatusReady].NotOK
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	tt.Run("overridden by another admin request", func(t *testing.T) {
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+		ready := adminHandler(AdminHandlers(pRes, BearerToken("alice", "s3cr3t")), HTTPPathAdminReady)
+
+		ready(httptest.NewRecorder(), adminReq(HTTPPathAdminReady+"?value=false&for=20ms", "s3cr3t"))
+		ready(httptest.NewRecorder(), adminReq(HTTPPathAdminReady+"?value=false&reason=maintenance", "s3cr3t"))
+		time.Sleep(60 * time.Millisecond)
+		assert.True(t, pRes.NotReady())
+		assert.Equal(t, "maintenance", pRes.Reason(proberesponder.StatusReady))
+	})
+
+	tt.Run("only with value false", func(t *testing.T) {
+		pRes := proberesponder.New()
+		ready := adminHandler(AdminHandlers(pRes, BearerToken("alice", "s3cr3t")), HTTPPathAdminReady)
+
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=true&for=20ms", "s3cr3t"))
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		assert.True(t, pRes.Statuses()[proberesponder.StatusReady].NotOK)
+	})
+}
+
+func TestAdminHandlers_WithDependencyProbes(tt *testing.T) {
+	SetLogger(nil)
+	defer SetLogger(logging.New(nil, nil))
+
+	// newProbed returns a ProbeResponder with ready set by a healthy dependency probe, every 5ms
+	newProbed := func(t *testing.T) *proberesponder.ProbeResponder {
+		pRes := proberesponder.New()
+		stopper := depprober.Start(5*time.Millisecond, pRes, &depprober.Probe{
+			ID:               "mydb",
+			AffectedStatuses: []proberesponder.Statuskey{proberesponder.StatusReady},
+		})
+		t.Cleanup(stopper.Stop)
+		assert.Eventually(t, func() bool {
+			return !pRes.Statuses()[proberesponder.StatusReady].NotOK
+		}, time.Second, time.Millisecond)
+		return pRes
+	}
+
+	tt.Run("drain is held", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newProbed(t)
+		ready := adminHandler(AdminHandlers(pRes, BearerToken("alice", "s3cr3t")), HTTPPathAdminReady)
+
+		ready(httptest.NewRecorder(), adminReq(HTTPPathAdminReady+"?value=false&reason=draining", "s3cr3t"))
+		time.Sleep(30 * time.Millisecond)
+		current := pRes.Statuses()[proberesponder.StatusReady]
+		asserter.True(current.NotOK)
+		asserter.Equal("draining", current.Reason)
+
+		ready(httptest.NewRecorder(), adminReq(HTTPPathAdminReady+"?value=true", "s3cr3t"))
+		asserter.False(pRes.Statuses()[proberesponder.StatusReady].NotOK)
+		time.Sleep(30 * time.Millisecond)
+		asserter.False(pRes.Statuses()[proberesponder.StatusReady].NotOK)
+	})
+
+	tt.Run("timed drain is released", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newProbed(t)
+		ready := adminHandler(AdminHandlers(pRes, BearerToken("alice", "s3cr3t")), HTTPPathAdminReady)
+
+		ready(httptest.NewRecorder(), adminReq(HTTPPathAdminReady+"?value=false&reason=draining&for=50ms", "s3cr3t"))
+		time.Sleep(25 * time.Millisecond)
+		asserter.True(pRes.Statuses()[proberesponder.StatusReady].NotOK)
+		asserter.Eventually(func() bool {
+			return !pRes.Statuses()[proberesponder.StatusReady].NotOK
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	tt.Run("ready does not release other holds", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newProbed(t)
+		ready := adminHandler(AdminHandlers(pRes, BearerToken("alice", "s3cr3t")), HTTPPathAdminReady)
+
+		release := pRes.Hold(proberesponder.StatusReady, "shutting down")
+		defer release()
+
+		w := httptest.NewRecorder()
+		ready(w, adminReq(HTTPPathAdminReady+"?value=true", "s3cr3t"))
+		asserter.Equal("ready: NOT OK (shutting down)", w.Body.String())
+		asserter.True(pRes.Statuses()[proberesponder.StatusReady].NotOK)
+	})
+}
+
+func TestWithAdmin(tt *testing.T) {
+	SetLogger(nil)
+	defer SetLogger(logging.New(nil, nil))
+
+	tt.Run("disabled by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewServer(proberesponder.New(), "", 1234).Handler.ServeHTTP(w, adminReq(HTTPPathAdminReady+"?value=true", "s3cr3t"))
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	tt.Run("enabled", func(t *testing.T) {
+		pRes := proberesponder.New()
+		srv := NewServer(pRes, "", 1234, WithAdmin(BearerToken("alice", "s3cr3t")))
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, adminReq(HTTPPathAdminReady+"?value=true", "s3cr3t"))
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		assert.False(t, pRes.NotReady())
+	})
+
+	tt.Run("nil authorizer", func(t *testing.T) {
+		pRes := proberesponder.New()
+		srv := NewServer(pRes, "", 1234, WithAdmin(nil))
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, adminReq(HTTPPathAdminReady+"?value=true", "s3cr3t"))
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+		assert.True(t, pRes.NotReady())
+	})
+}
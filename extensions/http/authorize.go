@@ -0,0 +1,133 @@
+package http
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+const (
+	httpHeaderAuthorization = "Authorization"
+	bearerPrefix            = "Bearer "
+)
+
+// Authorizer decides if the request is authorized to view the full details of the health
+// response. actor identifies the caller (e.g. the name of the token, or the client IP),
+// and is used for logging.
+type Authorizer func(r *http.Request) (actor string, ok bool)
+
+// BearerToken authorizes requests with the header "Authorization: Bearer <token>". The
+// token is compared in constant time.
+func BearerToken(actor, token string) Authorizer {
+	return func(r *http.Request) (string, bool) {
+		if token == "" {
+			return "", false
+		}
+
+		auth := r.Header.Get(httpHeaderAuthorization)
+		if len(auth) < len(bearerPrefix) || !strings.EqualFold(auth[:len(bearerPrefix)], bearerPrefix) {
+			return "", false
+		}
+
+		given := strings.TrimSpace(auth[len(bearerPrefix):])
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return "", false
+		}
+
+		return actor, true
+	}
+}
+
+// AllowCIDRs authorizes requests from clients within any of the CIDRs, e.g. 10.0.0.0/8.
+// Only the remote address of the connection is considered, headers like X-Forwarded-For
+// are ignored, since they can be set by the client. The actor is the client IP.
+func AllowCIDRs(cidrs ...string) (Authorizer, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return func(r *http.Request) (string, bool) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return "", false
+		}
+		addr = addr.Unmap()
+
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return addr.String(), true
+			}
+		}
+		return "", false
+	}, nil
+}
+
+// AnyOf authorizes the request if any of the authorizers do, the first one authorizing
+// the request decides the actor
+func AnyOf(auths ...Authorizer) Authorizer {
+	return func(r *http.Request) (string, bool) {
+		for _, auth := range auths {
+			if auth == nil {
+				continue
+			}
+			if actor, ok := auth(r); ok {
+				return actor, true
+			}
+		}
+		return "", false
+	}
+}
+
+// WithAuthorizer restricts the full details of the health response to the requests authorized
+// by auth. Unauthorized requests to the probes are responded with only the status code, and
+// the health status (OK / NOT OK) in plain text. Handlers without a status of their own, e.g.
+// HTTPDashboard & HTTPInfo, respond with 401 Unauthorized instead.
+func WithAuthorizer(auth Authorizer) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.authorizer = auth
+	}
+}
+
+// authorized returns true if there's no authorizer configured, or if the request is
+// authorized by it
+func (hcfg *handlerConfig) authorized(r *http.Request) bool {
+	if hcfg.authorizer == nil {
+		return true
+	}
+	_, ok := hcfg.authorizer(r)
+	return ok
+}
+
+// respondUnauthorized responds with 401, for the handlers without a status to respond with
+func (hcfg *handlerConfig) respondUnauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(httpHeaderContentType, httpHeaderContentTypePlain)
+	w.WriteHeader(http.StatusUnauthorized)
+	hcfg.write(w, r, []byte(http.StatusText(http.StatusUnauthorized)))
+}
+
+// respondBare responds with only the status code, and the health status in plain text
+func (hcfg *handlerConfig) respondBare(w http.ResponseWriter, r *http.Request, status int, notOK bool) {
+	hs := proberesponder.HealthOK
+	if notOK {
+		hs = proberesponder.HealthNotOK
+	}
+
+	w.Header().Add(httpHeaderContentType, httpHeaderContentTypePlain)
+	w.WriteHeader(status)
+	hcfg.write(w, r, []byte(hs.String()))
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearerToken(tt *testing.T) {
+	auth := BearerToken("ops", "s3cr3t")
+
+	tests := []struct {
+		name      string
+		header    string
+		wantActor string
+		wantOK    bool
+	}{
+		{name: "valid", header: "Bearer s3cr3t", wantActor: "ops", wantOK: true},
+		{name: "case insensitive scheme", header: "bearer s3cr3t", wantActor: "ops", wantOK: true},
+		{name: "invalid token", header: "Bearer wrong"},
+		{name: "prefix of token", header: "Bearer s3cr"},
+		{name: "basic auth", header: "Basic s3cr3t"},
+		{name: "missing"},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+			if tc.header != "" {
+				r.Header.Set(httpHeaderAuthorization, tc.header)
+			}
+			actor, ok := auth(r)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantActor, actor)
+		})
+	}
+
+	tt.Run("empty token", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+		r.Header.Set(httpHeaderAuthorization, "Bearer ")
+		_, ok := BearerToken("ops", "")(r)
+		assert.False(t, ok)
+	})
+}
+
+func TestAllowCIDRs(tt *testing.T) {
+	tt.Run("invalid CIDR", func(t *testing.T) {
+		_, err := AllowCIDRs("10.0.0.0/8", "not-a-cidr")
+		assert.Error(t, err)
+	})
+
+	auth, err := AllowCIDRs("10.0.0.0/8", " 192.168.1.0/24", "fd00::/8")
+	assert.NoError(tt, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		wantActor  string
+		wantOK     bool
+	}{
+		{name: "within range", remoteAddr: "10.1.2.3:4567", wantActor: "10.1.2.3", wantOK: true},
+		{name: "IPv4 mapped IPv6", remoteAddr: "[::ffff:192.168.1.10]:80", wantActor: "192.168.1.10", wantOK: true},
+		{name: "IPv6", remoteAddr: "[fd00::1]:80", wantActor: "fd00::1", wantOK: true},
+		{name: "without port", remoteAddr: "10.0.0.1", wantActor: "10.0.0.1", wantOK: true},
+		{name: "outside range", remoteAddr: "192.168.2.1:80"},
+		{name: "invalid address", remoteAddr: "localhost:80"},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+			r.RemoteAddr = tc.remoteAddr
+			actor, ok := auth(r)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantActor, actor)
+		})
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	asserter := assert.New(t)
+	cidrs, err := AllowCIDRs("10.0.0.0/8")
+	asserter.NoError(err)
+	auth := AnyOf(nil, BearerToken("ops", "s3cr3t"), cidrs)
+
+	r := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+	r.RemoteAddr = "192.168.1.1:80"
+	_, ok := auth(r)
+	asserter.False(ok)
+
+	r.Header.Set(httpHeaderAuthorization, "Bearer s3cr3t")
+	actor, ok := auth(r)
+	asserter.True(ok)
+	asserter.Equal("ops", actor)
+
+	r.Header.Del(httpHeaderAuthorization)
+	r.RemoteAddr = "10.0.0.1:80"
+	actor, ok = auth(r)
+	asserter.True(ok)
+	asserter.Equal("10.0.0.1", actor)
+}
+
+func TestWithAuthorizer(tt *testing.T) {
+	pRes := newResponderWithChecks(checkDBDown)
+	pRes.SetNotLive(false)
+	auth := BearerToken("ops", "s3cr3t")
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		target     string
+		token      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "unauthorized ready",
+			handler:    HTTPReady(pRes, WithAuthorizer(auth)),
+			target:     HTTPPathReady,
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "NOT OK",
+		},
+		{
+			name:       "unauthorized live",
+			handler:    HTTPLive(pRes, WithAuthorizer(auth)),
+			target:     HTTPPathLive,
+			wantStatus: http.StatusOK,
+			wantBody:   "OK",
+		},
+		{
+			name:       "unauthorized verbose",
+			handler:    HTTPReady(pRes, WithAuthorizer(auth)),
+			target:     HTTPPathReady + "?verbose",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "NOT OK",
+		},
+		{
+			name:       "unauthorized info",
+			handler:    HTTPInfo(WithAuthorizer(auth)),
+			target:     HTTPPathInfo,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Unauthorized",
+		},
+		{
+			name:       "unauthorized dashboard",
+			handler:    HTTPDashboard(pRes, WithAuthorizer(auth)),
+			target:     HTTPPathDashboard,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "Unauthorized",
+		},
+		{
+			name:       "authorized ready",
+			handler:    HTTPReady(pRes, WithAuthorizer(auth)),
+			target:     HTTPPathReady + "?verbose",
+			token:      "s3cr3t",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "[-]mydb failed: connection refused",
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			r.Header.Set(httpHeaderAccept, httpHeaderContentTypeJSON)
+			if tc.token != "" {
+				r.Header.Set(httpHeaderAuthorization, "Bearer "+tc.token)
+			}
+			tc.handler(w, r)
+
+			asserter.Equal(tc.wantStatus, w.Result().StatusCode)
+			if tc.token == "" {
+				asserter.Equal(tc.wantBody, w.Body.String())
+				asserter.Equal(httpHeaderContentTypePlain, w.Header().Get(httpHeaderContentType))
+				return
+			}
+			asserter.Contains(w.Body.String(), tc.wantBody)
+		})
+	}
+}
+
+func TestNewServer(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.SetNotReady(false)
+	pRes.AppendHealthResponse("mydb", "OK")
+
+	srv := NewServer(
+		pRes, "", 1234,
+		WithHandlerOptions(WithAuthorizer(BearerToken("ops", "s3cr3t"))),
+		WithHandlers(Handler{Method: http.MethodGet, Path: HTTPPathInfo, Handler: HTTPInfo()}),
+	)
+
+	tt.Run("unauthorized", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HTTPPathReady, nil))
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Equal("OK", w.Body.String())
+	})
+
+	tt.Run("authorized", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+		r.Header.Set(httpHeaderAuthorization, "Bearer s3cr3t")
+		srv.Handler.ServeHTTP(w, r)
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Contains(w.Body.String(), "mydb")
+	})
+
+	tt.Run("additional handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HTTPPathInfo, nil))
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+}
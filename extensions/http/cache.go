@@ -0,0 +1,108 @@
+package http
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpHeaderETag        = "ETag"
+	httpHeaderIfNoneMatch = "If-None-Match"
+
+	// DefaultDynamicRefresh is the duration for which a cached response is reused, if the health
+	// response has values computed on read
+	DefaultDynamicRefresh = time.Second
+)
+
+// WithDynamicRefresh sets the duration for which a cached response is reused, if the health
+// response has values computed on read (e.g. uptime, in-flight requests), instead of
+// DefaultDynamicRefresh. Such values are stale by at most the duration, while the rest of the
+// state is never stale. A duration less than or equal to 0 disables caching of such responses.
+func WithDynamicRefresh(d time.Duration) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.dynamicRefresh = d
+	}
+}
+
+// cacheKey identifies the variants of a probe response, for the same version of the state
+type cacheKey struct {
+	mediaType string
+	encoding  string
+	all       bool
+}
+
+type cachedResponse struct {
+	body []byte
+	etag string
+	// encoding is the content encoding of the body, empty if not compressed
+	encoding string
+	// cachedAt is when the response was encoded, to refresh values computed on read
+	cachedAt time.Time
+}
+
+// responseCache has the encoded responses of a single version of the state. The responses of
+// older versions are discarded as soon as a newer version is cached.
+type responseCache struct {
+	locker    *sync.Mutex
+	version   uint64
+	responses map[cacheKey]cachedResponse
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		locker:    &sync.Mutex{},
+		responses: map[cacheKey]cachedResponse{},
+	}
+}
+
+func (rc *responseCache) get(version uint64, key cacheKey) (cachedResponse, bool) {
+	rc.locker.Lock()
+	defer rc.locker.Unlock()
+
+	if version != rc.version {
+		return cachedResponse{}, false
+	}
+	cr, ok := rc.responses[key]
+	return cr, ok
+}
+
+func (rc *responseCache) put(version uint64, key cacheKey, cr cachedResponse) {
+	rc.locker.Lock()
+	defer rc.locker.Unlock()
+
+	switch {
+	case version < rc.version:
+		return
+	case version > rc.version:
+		rc.version = version
+		clear(rc.responses)
+	}
+	rc.responses[key] = cr
+}
+
+// etag returns a strong ETag of the response, the status code is included since it is not
+// always derived from the body alone. e.g. degraded
+func etag(status int, body []byte) string {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(strconv.Itoa(status)))
+	_, _ = hash.Write(body)
+	return `"` + strconv.FormatUint(hash.Sum64(), 16) + `"`
+}
+
+// notModified returns true if any of the ETags in the If-None-Match header match the etag, as
+// per the weak comparison of RFC 9110
+func notModified(r *http.Request, etag string) bool {
+	for _, value := range r.Header.Values(httpHeaderIfNoneMatch) {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
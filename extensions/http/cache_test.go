@@ -0,0 +1,172 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	asserter := assert.New(t)
+	rc := newResponseCache()
+	key := cacheKey{mediaType: httpHeaderContentTypeJSON}
+
+	_, ok := rc.get(0, key)
+	asserter.False(ok)
+
+	rc.put(2, key, cachedResponse{etag: "v2"})
+	cr, ok := rc.get(2, key)
+	asserter.True(ok)
+	asserter.Equal("v2", cr.etag)
+
+	_, ok = rc.get(2, cacheKey{mediaType: httpHeaderContentTypeJSON, all: true})
+	asserter.False(ok)
+
+	// older versions are neither cached nor responded
+	rc.put(1, key, cachedResponse{etag: "v1"})
+	_, ok = rc.get(1, key)
+	asserter.False(ok)
+	cr, _ = rc.get(2, key)
+	asserter.Equal("v2", cr.etag)
+
+	// newer versions discard the older
+	rc.put(3, cacheKey{mediaType: httpHeaderContentTypeXML}, cachedResponse{etag: "v3"})
+	_, ok = rc.get(2, key)
+	asserter.False(ok)
+	_, ok = rc.get(3, key)
+	asserter.False(ok)
+}
+
+func TestHTTPReady_ConditionalRequests(tt *testing.T) {
+	get := func(handler http.HandlerFunc, query, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, HTTPPathReady+query, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set(httpHeaderIfNoneMatch, ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	tt.Run("not modified", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newResponderWithChecks(checkCacheOK)
+		handler := HTTPReady(pRes)
+
+		first := get(handler, "", "")
+		asserter.Equal(http.StatusOK, first.Result().StatusCode)
+		tag := first.Header().Get(httpHeaderETag)
+		asserter.NotEmpty(tag)
+
+		second := get(handler, "", "")
+		asserter.Equal(tag, second.Header().Get(httpHeaderETag))
+		asserter.Equal(first.Body.String(), second.Body.String())
+
+		for _, ifNoneMatch := range []string{tag, `"other", ` + tag, "W/" + tag, "*"} {
+			w := get(handler, "", ifNoneMatch)
+			asserter.Equal(http.StatusNotModified, w.Result().StatusCode, ifNoneMatch)
+			asserter.Empty(w.Body.String())
+			asserter.Equal(tag, w.Header().Get(httpHeaderETag))
+		}
+
+		w := get(handler, "", `"other"`)
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Equal(first.Body.String(), w.Body.String())
+
+		// all entries is a different variant
+		w = get(handler, "?all", tag)
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.NotEqual(tag, w.Header().Get(httpHeaderETag))
+	})
+
+	tt.Run("modified", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newResponderWithChecks(checkCacheOK)
+		handler := HTTPReady(pRes)
+
+		first := get(handler, "", "")
+		tag := first.Header().Get(httpHeaderETag)
+
+		pRes.AppendHealthResponse("version", "v1.2.3")
+		w := get(handler, "", tag)
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Contains(w.Body.String(), "v1.2.3")
+		asserter.NotEqual(tag, w.Header().Get(httpHeaderETag))
+	})
+
+	tt.Run("failing status is never not modified", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newResponderWithChecks(checkDBDown)
+		handler := HTTPReady(pRes)
+
+		tag := get(handler, "", "").Header().Get(httpHeaderETag)
+		w := get(handler, "", tag)
+		asserter.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+		asserter.NotEmpty(w.Body.String())
+	})
+
+	tt.Run("degraded is not modified", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newResponderWithChecks(checkCacheOK, checkLiveDn)
+		handler := HTTPReady(pRes, WithStatusCode(ProbeStateDegraded, http.StatusMultiStatus))
+
+		first := get(handler, "", "")
+		asserter.Equal(http.StatusMultiStatus, first.Result().StatusCode)
+		asserter.Equal([]string{"Accept, Accept-Encoding"}, first.Header().Values(httpHeaderVary))
+
+		w := get(handler, "", first.Header().Get(httpHeaderETag))
+		asserter.Equal(http.StatusNotModified, w.Result().StatusCode)
+	})
+
+	tt.Run("values computed on read", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newResponderWithChecks(checkCacheOK)
+		counter := 0
+		pRes.AppendHealthResponseFunc("counter", func() string {
+			counter++
+			return strconv.Itoa(counter)
+		})
+
+		// cached within the refresh duration
+		handler := HTTPReady(pRes)
+		first := get(handler, "", "")
+		tag := first.Header().Get(httpHeaderETag)
+		w := get(handler, "", "")
+		asserter.Equal(first.Body.String(), w.Body.String())
+		w = get(handler, "", tag)
+		asserter.Equal(http.StatusNotModified, w.Result().StatusCode)
+
+		// refreshed after the refresh duration
+		handler = HTTPReady(pRes, WithDynamicRefresh(10*time.Millisecond))
+		first = get(handler, "", "")
+		time.Sleep(20 * time.Millisecond)
+		w = get(handler, "", first.Header().Get(httpHeaderETag))
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.NotEqual(first.Body.String(), w.Body.String())
+		asserter.NotEqual(first.Header().Get(httpHeaderETag), w.Header().Get(httpHeaderETag))
+
+		// not cached
+		handler = HTTPReady(pRes, WithDynamicRefresh(0))
+		first = get(handler, "", "")
+		w = get(handler, "", first.Header().Get(httpHeaderETag))
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.NotEqual(first.Body.String(), w.Body.String())
+	})
+
+	tt.Run("changes are not stale with values computed on read", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := newResponderWithChecks(checkCacheOK)
+		pRes.AppendHealthResponseFunc("counter", func() string { return "1" })
+		handler := HTTPReady(pRes, WithDynamicRefresh(time.Hour))
+
+		first := get(handler, "", "")
+		pRes.AppendHealthResponse("version", "v1.2.3")
+		w := get(handler, "", first.Header().Get(httpHeaderETag))
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Contains(w.Body.String(), "v1.2.3")
+	})
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	httpHeaderAcceptEncoding  = "Accept-Encoding"
+	httpHeaderContentEncoding = "Content-Encoding"
+	httpHeaderVary            = "Vary"
+
+	contentEncodingGzip    = "gzip"
+	contentEncodingDeflate = "deflate"
+
+	// DefaultCompressionThreshold is the size in bytes of the encoded response, below which it
+	// is not compressed
+	DefaultCompressionThreshold = 1024
+)
+
+// contentEncodings are the supported encodings, in the order of preference
+var contentEncodings = []string{contentEncodingGzip, contentEncodingDeflate}
+
+// WithCompressionThreshold sets the size in bytes of the encoded response, below which it is
+// not compressed, instead of DefaultCompressionThreshold. A negative threshold disables
+// compression.
+func WithCompressionThreshold(threshold int) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.compressionThreshold = threshold
+	}
+}
+
+func (hcfg *handlerConfig) compressionEnabled() bool {
+	return hcfg.compressionThreshold >= 0
+}
+
+// contentEncoding returns the encoding most preferred by the Accept-Encoding header, among
+// gzip & deflate. It returns an empty string if neither is acceptable, or compression is
+// disabled.
+func (hcfg *handlerConfig) contentEncoding(r *http.Request) string {
+	if !hcfg.compressionEnabled() {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	wildcard := -1.0
+	for _, value := range r.Header.Values(httpHeaderAcceptEncoding) {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+
+			quality := 1.0
+			if qv, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				q, err := strconv.ParseFloat(qv, 64)
+				if err != nil {
+					continue
+				}
+				quality = q
+			}
+
+			if name == "*" {
+				wildcard = quality
+				continue
+			}
+			qualities[name] = quality
+		}
+	}
+
+	preferred, preferredQ := "", 0.0
+	for _, encoding := range contentEncodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality = wildcard
+		}
+		if quality > preferredQ {
+			preferred, preferredQ = encoding, quality
+		}
+	}
+
+	return preferred
+}
+
+// compressResponse returns the response with the body compressed with the encoding, if the
+// body is not smaller than the threshold. Otherwise the response is returned as is.
+func (hcfg *handlerConfig) compressResponse(cr cachedResponse, encoding string) cachedResponse {
+	if encoding == "" || len(cr.body) < hcfg.compressionThreshold {
+		return cr
+	}
+
+	body, err := compress(encoding, cr.body)
+	if err != nil {
+		return cr
+	}
+
+	return cachedResponse{
+		body:     body,
+		etag:     strings.TrimSuffix(cr.etag, `"`) + "-" + encoding + `"`,
+		encoding: encoding,
+	}
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	buff := bytes.NewBuffer(nil)
+
+	var cw io.WriteCloser
+	switch encoding {
+	case contentEncodingGzip:
+		cw = gzip.NewWriter(buff)
+	default:
+		// deflate in HTTP is the zlib format, as per RFC 9110
+		cw = zlib.NewWriter(buff)
+	}
+
+	_, err := cw.Write(body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
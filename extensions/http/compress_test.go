@@ -0,0 +1,140 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentEncoding(tt *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		opts           []HandlerOption
+		want           string
+	}{
+		{name: "none", acceptEncoding: "", want: ""},
+		{name: "identity", acceptEncoding: "identity", want: ""},
+		{name: "gzip", acceptEncoding: "gzip", want: contentEncodingGzip},
+		{name: "deflate", acceptEncoding: "deflate", want: contentEncodingDeflate},
+		{name: "gzip preferred on tie", acceptEncoding: "deflate, gzip", want: contentEncodingGzip},
+		{name: "quality", acceptEncoding: "gzip;q=0.5, deflate", want: contentEncodingDeflate},
+		{name: "case insensitive", acceptEncoding: "GZIP", want: contentEncodingGzip},
+		{name: "wildcard", acceptEncoding: "*", want: contentEncodingGzip},
+		{name: "wildcard with exclusion", acceptEncoding: "*, gzip;q=0", want: contentEncodingDeflate},
+		{name: "not acceptable", acceptEncoding: "gzip;q=0, br", want: ""},
+		{name: "invalid quality", acceptEncoding: "gzip;q=x, deflate", want: contentEncodingDeflate},
+		{
+			name:           "disabled",
+			acceptEncoding: "gzip",
+			opts:           []HandlerOption{WithCompressionThreshold(-1)},
+			want:           "",
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+			req.Header.Set(httpHeaderAcceptEncoding, tc.acceptEncoding)
+			assert.Equal(t, tc.want, newHandlerConfig(tc.opts...).contentEncoding(req))
+		})
+	}
+}
+
+func TestHTTPReady_Compression(tt *testing.T) {
+	pRes := newResponderWithChecks(checkCacheOK)
+	pRes.AppendHealthResponse("large", strings.Repeat("lorem ipsum ", 200))
+
+	get := func(handler http.HandlerFunc, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, HTTPPathReady, nil)
+		req.Header.Set(httpHeaderAcceptEncoding, acceptEncoding)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	uncompressed := get(HTTPReady(pRes), "")
+	require.Equal(tt, http.StatusOK, uncompressed.Result().StatusCode)
+
+	tests := []struct {
+		name           string
+		opts           []HandlerOption
+		acceptEncoding string
+		wantEncoding   string
+		wantVary       string
+		decompress     func(r io.Reader) (io.Reader, error)
+	}{
+		{
+			name:     "not accepted",
+			wantVary: "Accept, Accept-Encoding",
+		},
+		{
+			name:           "gzip",
+			acceptEncoding: "gzip, deflate",
+			wantEncoding:   contentEncodingGzip,
+			wantVary:       "Accept, Accept-Encoding",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			name:           "deflate",
+			acceptEncoding: "deflate",
+			wantEncoding:   contentEncodingDeflate,
+			wantVary:       "Accept, Accept-Encoding",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return zlib.NewReader(r)
+			},
+		},
+		{
+			name:           "below threshold",
+			opts:           []HandlerOption{WithCompressionThreshold(1 << 20)},
+			acceptEncoding: "gzip",
+			wantVary:       "Accept, Accept-Encoding",
+		},
+		{
+			name:           "disabled",
+			opts:           []HandlerOption{WithCompressionThreshold(-1)},
+			acceptEncoding: "gzip",
+			wantVary:       httpHeaderAccept,
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			handler := HTTPReady(pRes, tc.opts...)
+
+			// the second response is served from the cache
+			for i := 0; i < 2; i++ {
+				w := get(handler, tc.acceptEncoding)
+				asserter.Equal(http.StatusOK, w.Result().StatusCode)
+				asserter.Equal(tc.wantEncoding, w.Header().Get(httpHeaderContentEncoding))
+				asserter.Equal(tc.wantVary, w.Header().Get(httpHeaderVary))
+				asserter.Equal(httpHeaderContentTypeJSON, w.Header().Get(httpHeaderContentType))
+
+				if tc.decompress == nil {
+					asserter.Equal(uncompressed.Body.String(), w.Body.String())
+					asserter.Equal(uncompressed.Header().Get(httpHeaderETag), w.Header().Get(httpHeaderETag))
+					continue
+				}
+
+				asserter.Less(w.Body.Len(), uncompressed.Body.Len())
+				asserter.NotEqual(uncompressed.Header().Get(httpHeaderETag), w.Header().Get(httpHeaderETag))
+
+				reader, err := tc.decompress(w.Body)
+				require.NoError(t, err)
+				body, err := io.ReadAll(reader)
+				require.NoError(t, err)
+				asserter.Equal(uncompressed.Body.String(), string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/naughtygopher/proberesponder/extensions/metadata"
+)
+
+const (
+	HTTPPathDashboard = "/-/dashboard"
+
+	// DefaultDashboardRefresh is the interval at which the dashboard reloads itself, unless
+	// live updates are enabled
+	DefaultDashboardRefresh = 10 * time.Second
+)
+
+var (
+	//go:embed dashboard.html
+	dashboardHTML string
+
+	dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+		"timefmt": func(t time.Time) string {
+			if t.IsZero() {
+				return "-"
+			}
+			return t.Format(time.RFC3339)
+		},
+	}).Parse(dashboardHTML))
+)
+
+type dashboardStatus struct {
+	Name string
+	proberesponder.StatusSnapshot
+}
+
+type dashboardData struct {
+	Title       string
+	GeneratedAt time.Time
+	// Refresh is the interval in seconds, at which the page reloads itself
+	Refresh int
+	// EventsPath is the path of HTTPEvents, for live updates
+	EventsPath string
+	Statuses   []dashboardStatus
+	Checks     []proberesponder.CheckResult
+	// History is the transitions, newest first
+	History []proberesponder.Transition
+	// Payload is all the entries which are not statuses or checks
+	Payload []Entry
+}
+
+// WithRefreshInterval sets the interval at which the dashboard reloads itself. An interval
+// less than 1 second disables it.
+func WithRefreshInterval(interval time.Duration) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.refresh = interval
+	}
+}
+
+// WithLiveUpdates updates the dashboard as soon as there are changes, using the events
+// streamed by HTTPEvents served at eventsPath. It replaces the periodic refresh. The browser's
+// EventSource cannot send an Authorization header, so if the events are restricted using
+// WithAuthorizer, it has to authorize the browser otherwise, e.g. AllowCIDRs or a custom
+// Authorizer (cookies are sent).
+func WithLiveUpdates(eventsPath string) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.eventsPath = eventsPath
+	}
+}
+
+// HTTPDashboard responds with an HTML page showing the probe statuses, the dependency checks
+// with their latency, the history of transitions, and the rest of the health response. The
+// page is self contained, and does not load any external assets. It reloads itself every
+// DefaultDashboardRefresh, or as configured using WithRefreshInterval or WithLiveUpdates.
+func HTTPDashboard(pres *proberesponder.ProbeResponder, opts ...HandlerOption) http.HandlerFunc {
+	hcfg := newHandlerConfig(append([]HandlerOption{WithRefreshInterval(DefaultDashboardRefresh)}, opts...)...)
+	title := metadata.Read().Module
+	if title == "" {
+		title = "proberesponder"
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !hcfg.authorized(r) {
+			hcfg.respondUnauthorized(w, r)
+			return
+		}
+
+		snap := hcfg.redactor.redactSnapshot(pres.Snapshot())
+		data := dashboardData{
+			Title:       title,
+			GeneratedAt: snap.TakenAt,
+			Refresh:     int(hcfg.refresh / time.Second),
+			EventsPath:  hcfg.eventsPath,
+			Checks:      snap.Checks,
+			History:     make([]proberesponder.Transition, 0, len(snap.History)),
+		}
+
+		for _, status := range []proberesponder.Statuskey{
+			proberesponder.StatusStartup,
+			proberesponder.StatusReady,
+			proberesponder.StatusLive,
+		} {
+			data.Statuses = append(data.Statuses, dashboardStatus{
+				Name:           status.String(),
+				StatusSnapshot: snap.Statuses[status],
+			})
+		}
+
+		for i := len(snap.History) - 1; i >= 0; i-- {
+			data.History = append(data.History, snap.History[i])
+		}
+
+		excluded := make(map[string]bool, len(snap.Checks))
+		for _, cr := range snap.Checks {
+			excluded[cr.ID] = true
+		}
+		payload := make(map[string]string, len(snap.Payload))
+		for key, ps := range snap.Payload {
+			if isProbeKey(key) || excluded[key] {
+				continue
+			}
+			payload[key] = ps.Value
+			if ps.Stale {
+				payload[key] += proberesponder.RestoredSuffix
+			}
+		}
+		data.Payload = orderEntries(hcfg.ordering, payload, pres.HealthResponseKeys())
+
+		buff := bytes.NewBuffer(nil)
+		err := dashboardTemplate.Execute(buff, data)
+		if err != nil {
+			hcfg.logger.log().Log(
+				r.Context(),
+				logging.EventEncodeFailure,
+				"failed to render dashboard",
+				slog.String("path", r.URL.Path),
+				slog.String("error", err.Error()),
+			)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add(httpHeaderContentType, httpHeaderContentTypeHTML+"; charset=utf-8")
+		w.Header().Set(httpHeaderCacheControl, "no-store")
+		w.WriteHeader(http.StatusOK)
+		hcfg.write(w, r, buff.Bytes())
+	}
+}
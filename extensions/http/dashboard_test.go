@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPDashboard(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.SetNotStarted(false)
+	pRes.SetStatus(proberesponder.StatusReady, true, "warming <cache>")
+	pRes.AppendHealthResponse("version", "v1.2.3")
+	pRes.AppendHealthResponse("db_password", "hunter2")
+	pRes.SetCheckResult(proberesponder.CheckResult{
+		ID:               "mydb",
+		NotOK:            true,
+		Error:            "dial postgres://user:pass@db:5432 refused",
+		AffectedStatuses: []proberesponder.Statuskey{proberesponder.StatusReady},
+		Latency:          42 * time.Millisecond,
+		CheckedAt:        time.Date(2025, 1, 9, 17, 45, 24, 0, time.UTC),
+	})
+
+	tt.Run("default", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPDashboard(pRes, WithRedactor(DefaultRedactor()))(w, httptest.NewRequest(http.MethodGet, HTTPPathDashboard, nil))
+
+		body := w.Body.String()
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.True(strings.HasPrefix(w.Header().Get(httpHeaderContentType), httpHeaderContentTypeHTML))
+		asserter.True(strings.HasPrefix(body, "<!DOCTYPE html>"))
+		asserter.Contains(body, `<meta http-equiv="refresh" content="10">`)
+		asserter.NotContains(body, "EventSource")
+		asserter.NotContains(body, "<script src")
+		asserter.NotContains(body, "<link")
+
+		// statuses
+		asserter.Contains(body, `<div class="name">startup</div>`+"\n\t\t"+`<span class="badge ok">OK</span>`)
+		asserter.Contains(body, `<div class="name">ready</div>`+"\n\t\t"+`<span class="badge notok">NOT OK</span>`)
+		asserter.Contains(body, "warming &lt;cache&gt;")
+
+		// checks
+		asserter.Contains(body, "<td>mydb</td>")
+		asserter.Contains(body, "<td>42ms</td>")
+		asserter.Contains(body, "<td>2025-01-09T17:45:24Z</td>")
+		asserter.Contains(body, "dial postgres://db:5432 refused")
+
+		// payload, excluding statuses & checks
+		asserter.Contains(body, "<tr><th>version</th><td>v1.2.3</td></tr>")
+		asserter.Contains(body, "<tr><th>db_password</th><td>"+RedactedValue+"</td></tr>")
+		asserter.NotContains(body, "hunter2")
+		asserter.NotContains(body, "<th>mydb</th>")
+		asserter.NotContains(body, "probe-&gt;")
+
+		// history, newest first
+		asserter.Less(strings.Index(body, "<td>startup</td>"), strings.Index(body, "<td>live</td>"))
+	})
+
+	tt.Run("live updates", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPDashboard(pRes, WithLiveUpdates(HTTPPathEvents))(w, httptest.NewRequest(http.MethodGet, HTTPPathDashboard, nil))
+
+		body := w.Body.String()
+		asserter.Contains(body, `new EventSource("/-/events")`)
+		asserter.NotContains(body, `http-equiv="refresh"`)
+	})
+
+	tt.Run("refresh disabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HTTPDashboard(pRes, WithRefreshInterval(0))(w, httptest.NewRequest(http.MethodGet, HTTPPathDashboard, nil))
+		assert.NotContains(t, w.Body.String(), `http-equiv="refresh"`)
+	})
+
+	tt.Run("unauthorized", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		HTTPDashboard(pRes, WithAuthorizer(BearerToken("ops", "s3cr3t")))(w, httptest.NewRequest(http.MethodGet, HTTPPathDashboard, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+		assert.NotContains(t, w.Body.String(), "OK")
+	})
+
+	tt.Run("empty", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPDashboard(proberesponder.New(proberesponder.WithHistorySize(0)))(w, httptest.NewRequest(http.MethodGet, HTTPPathDashboard, nil))
+		body := w.Body.String()
+		asserter.Contains(body, "No dependency checks")
+		asserter.Contains(body, "No transitions")
+		asserter.Contains(body, "No details")
+	})
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+var (
+	JSONEncoder      = NewEncoder(httpHeaderContentTypeJSON, encodeJSON)
+	HTMLEncoder      = NewEncoder(httpHeaderContentTypeHTML, encodeHTML)
+	PlainTextEncoder = NewEncoder(httpHeaderContentTypePlain, encodePlainText)
+	XMLEncoder       = NewEncoder(httpHeaderContentTypeXML, encodeXML)
+
+	// HealthJSONEncoder encodes as per the health check response format draft, with the module
+	// path as the service ID and the module version (or VCS revision) as the release ID
+	HealthJSONEncoder = NewHealthJSONEncoder(defaultServiceIDs())
+
+	// DefaultEncoderRegistry is used by all the handlers, unless configured otherwise
+	// using WithEncoderRegistry
+	DefaultEncoderRegistry = NewEncoderRegistry(
+		JSONEncoder,
+		HTMLEncoder,
+		PlainTextEncoder,
+		XMLEncoder,
+		HealthJSONEncoder,
+	)
+
+	// htmlTemplate escapes all the keys & values based on context
+	htmlTemplate = template.Must(template.New("payload").Parse(
+		`<table><tbody>{{range .}}` +
+			`<tr><th>{{.Key}}</th><td>{{.Value}}</td></tr>` +
+			`{{end}}</tbody></table>`,
+	))
+)
+
+// Report is what's encoded as the response of the handlers
+type Report struct {
+	// Status is the probe status queried, it is empty for non-probe handlers (e.g. HTTPInfo)
+	Status proberesponder.Statuskey
+	NotOK  bool
+	// Payload is the health response, after redaction
+	Payload map[string]string
+	// Entries are all the key value pairs of Payload, in the configured order
+	Entries []Entry
+	// Probes are the states of all the probe statuses, it is empty for non-probe handlers
+	Probes map[proberesponder.Statuskey]proberesponder.StatusSnapshot
+	// Checks are the latest results of the dependency checks, after redaction
+	Checks []proberesponder.CheckResult
+}
+
+// entries returns Entries, or the entries of Payload sorted by key, if Entries is not set
+func (rep Report) entries() []Entry {
+	if rep.Entries == nil {
+		return orderEntries(OrderSorted, rep.Payload, nil)
+	}
+	return rep.Entries
+}
+
+// Encoder encodes the report for a single media type
+type Encoder interface {
+	// MediaType is the content type of the encoded report, e.g. application/json
+	MediaType() string
+	Encode(w io.Writer, rep Report) error
+}
+
+type encoder struct {
+	mediaType string
+	encodeFn  func(w io.Writer, rep Report) error
+}
+
+func (enc *encoder) MediaType() string {
+	return enc.mediaType
+}
+
+func (enc *encoder) Encode(w io.Writer, rep Report) error {
+	return enc.encodeFn(w, rep)
+}
+
+// NewEncoder returns an Encoder for the media type, which uses encodeFn for encoding
+func NewEncoder(mediaType string, encodeFn func(w io.Writer, rep Report) error) Encoder {
+	return &encoder{
+		mediaType: mediaType,
+		encodeFn:  encodeFn,
+	}
+}
+
+// EncoderRegistry maintains the encoders available for content negotiation. The order of
+// registration is the order of preference, when the client has no preference. The first
+// encoder is also used when the client does not accept any of the media types.
+type EncoderRegistry struct {
+	locker   *sync.RWMutex
+	encoders []Encoder
+}
+
+func NewEncoderRegistry(encoders ...Encoder) *EncoderRegistry {
+	reg := &EncoderRegistry{
+		locker: &sync.RWMutex{},
+	}
+	for _, enc := range encoders {
+		reg.Register(enc)
+	}
+	return reg
+}
+
+// Register adds the encoder to the registry. If an encoder of the same media type is already
+// registered, it is replaced, while retaining its order.
+func (reg *EncoderRegistry) Register(enc Encoder) {
+	if enc == nil {
+		return
+	}
+	reg.locker.Lock()
+	defer reg.locker.Unlock()
+
+	for i, existing := range reg.encoders {
+		if strings.EqualFold(existing.MediaType(), enc.MediaType()) {
+			reg.encoders[i] = enc
+			return
+		}
+	}
+	reg.encoders = append(reg.encoders, enc)
+}
+
+// MediaTypes returns the media types of all the registered encoders, in the order of preference
+func (reg *EncoderRegistry) MediaTypes() []string {
+	reg.locker.RLock()
+	defer reg.locker.RUnlock()
+
+	mtypes := make([]string, 0, len(reg.encoders))
+	for _, enc := range reg.encoders {
+		mtypes = append(mtypes, enc.MediaType())
+	}
+	return mtypes
+}
+
+// Negotiate returns the encoder most preferred by the Accept header. If none of the encoders
+// are acceptable, the first encoder is returned, with acceptable as false.
+func (reg *EncoderRegistry) Negotiate(accept string) (enc Encoder, acceptable bool) {
+	reg.locker.RLock()
+	defer reg.locker.RUnlock()
+
+	if len(reg.encoders) == 0 {
+		return nil, false
+	}
+
+	mtypes := make([]string, 0, len(reg.encoders))
+	for _, enc := range reg.encoders {
+		mtypes = append(mtypes, enc.MediaType())
+	}
+
+	mtype, acceptable := negotiate(accept, mtypes)
+	if !acceptable {
+		return reg.encoders[0], false
+	}
+
+	for _, enc := range reg.encoders {
+		if enc.MediaType() == mtype {
+			return enc, true
+		}
+	}
+
+	return reg.encoders[0], false
+}
+
+// RegisterEncoder registers the encoder with DefaultEncoderRegistry
+func RegisterEncoder(enc Encoder) {
+	DefaultEncoderRegistry.Register(enc)
+}
+
+// encodeJSON encodes the entries as a JSON object, the keys of the object are in the
+// same order as the entries
+func encodeJSON(w io.Writer, rep Report) error {
+	buff := bytes.NewBufferString("{")
+	for i, entry := range rep.entries() {
+		if i > 0 {
+			buff.WriteByte(',')
+		}
+		key, _ := json.Marshal(entry.Key)
+		value, _ := json.Marshal(entry.Value)
+		buff.Write(key)
+		buff.WriteByte(':')
+		buff.Write(value)
+	}
+	buff.WriteString("}\n")
+
+	_, err := w.Write(buff.Bytes())
+	return err
+}
+
+func encodeHTML(w io.Writer, rep Report) error {
+	return htmlTemplate.Execute(w, rep.entries())
+}
+
+func encodePlainText(w io.Writer, rep Report) error {
+	buff := bytes.NewBuffer([]byte{})
+	for _, entry := range rep.entries() {
+		buff.WriteString(fmt.Sprintf("%s: %s | ", entry.Key, entry.Value))
+	}
+	_, err := w.Write(buff.Bytes())
+	return err
+}
+
+// XMLStatuses is the root element of the XML response, e.g.
+//
+//	<statuses><status name="probe->live" value="OK: 2025-01-09T17:45:24+01:00"></status></statuses>
+type XMLStatuses struct {
+	XMLName  xml.Name    `xml:"statuses"`
+	Statuses []XMLStatus `xml:"status"`
+}
+
+// XMLStatus is a single key value pair of the health response
+type XMLStatus struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+func encodeXML(w io.Writer, rep Report) error {
+	entries := rep.entries()
+	statuses := XMLStatuses{
+		Statuses: make([]XMLStatus, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		statuses.Statuses = append(statuses.Statuses, XMLStatus{
+			Name:  entry.Key,
+			Value: entry.Value,
+		})
+	}
+
+	return xml.NewEncoder(w).Encode(statuses)
+}
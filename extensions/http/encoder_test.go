@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+const envelopeMediaType = "application/vnd.envelope+json"
+
+func envelopeEncoder(prefix string) Encoder {
+	return NewEncoder(envelopeMediaType, func(w io.Writer, rep Report) error {
+		_, err := fmt.Fprintf(w, `{"%s":{"status":%q,"notOK":%t}}`, prefix, rep.Status, rep.NotOK)
+		return err
+	})
+}
+
+func TestEncoderRegistry(tt *testing.T) {
+	tt.Run("register & replace", func(t *testing.T) {
+		asserter := assert.New(t)
+		reg := NewEncoderRegistry(JSONEncoder, PlainTextEncoder)
+		reg.Register(envelopeEncoder("v1"))
+		reg.Register(nil)
+		asserter.Equal(
+			[]string{httpHeaderContentTypeJSON, httpHeaderContentTypePlain, envelopeMediaType},
+			reg.MediaTypes(),
+		)
+
+		replacement := NewEncoder(httpHeaderContentTypeJSON, func(w io.Writer, rep Report) error {
+			return nil
+		})
+		reg.Register(replacement)
+		asserter.Equal(
+			[]string{httpHeaderContentTypeJSON, httpHeaderContentTypePlain, envelopeMediaType},
+			reg.MediaTypes(),
+		)
+		enc, acceptable := reg.Negotiate(httpHeaderContentTypeJSON)
+		asserter.True(acceptable)
+		asserter.Equal(replacement, enc)
+	})
+
+	tt.Run("negotiate", func(t *testing.T) {
+		asserter := assert.New(t)
+		reg := NewEncoderRegistry(PlainTextEncoder, JSONEncoder)
+
+		enc, acceptable := reg.Negotiate("")
+		asserter.True(acceptable)
+		asserter.Equal(PlainTextEncoder, enc)
+
+		enc, acceptable = reg.Negotiate("application/*")
+		asserter.True(acceptable)
+		asserter.Equal(JSONEncoder, enc)
+
+		enc, acceptable = reg.Negotiate("image/png")
+		asserter.False(acceptable)
+		asserter.Equal(PlainTextEncoder, enc)
+
+		enc, acceptable = NewEncoderRegistry().Negotiate("*/*")
+		asserter.False(acceptable)
+		asserter.Nil(enc)
+	})
+}
+
+func TestWithEncoderRegistry(tt *testing.T) {
+	tt.Run("custom encoder", func(t *testing.T) {
+		asserter := assert.New(t)
+		reg := NewEncoderRegistry(JSONEncoder, envelopeEncoder("health"))
+		w := httptest.NewRecorder()
+		HTTPReady(proberesponder.New(), WithEncoderRegistry(reg))(w, httpReq(envelopeMediaType))
+
+		asserter.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+		asserter.Equal(envelopeMediaType, w.Header().Get(httpHeaderContentType))
+		asserter.Equal(httpHeaderContentTypeJSON+","+envelopeMediaType, w.Header().Get(httpHeaderAccept))
+		asserter.Equal(`{"health":{"status":"ready","notOK":true}}`, w.Body.String())
+	})
+
+	tt.Run("encoding failure", func(t *testing.T) {
+		asserter := assert.New(t)
+		SetLogger(nil)
+		defer SetLogger(logging.New(nil, nil))
+
+		reg := NewEncoderRegistry(NewEncoder(httpHeaderContentTypeJSON, func(w io.Writer, rep Report) error {
+			return errors.New("unsupported value")
+		}))
+		w := httptest.NewRecorder()
+		HTTPReady(proberesponder.New(), WithEncoderRegistry(reg))(w, httpReq(httpHeaderContentTypeJSON))
+
+		asserter.Equal(http.StatusInternalServerError, w.Result().StatusCode)
+		asserter.Empty(w.Body.String())
+	})
+
+	tt.Run("empty registry", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPReady(proberesponder.New(), WithEncoderRegistry(NewEncoderRegistry()))(w, httpReq(""))
+
+		asserter.Equal(http.StatusNotAcceptable, w.Result().StatusCode)
+	})
+}
+
+var encoderFuzzSeeds = [][2]string{
+	{"probe->live", "OK: 2025-01-09T17:45:24+01:00"},
+	{"mydb", `NOT OK: <script>alert("xss")</script>`},
+	{`"><img src=x onerror=alert(1)>`, "' OR 1=1 --"},
+	{"cdata", "]]><![CDATA[ & &amp; &#x0;"},
+	{"control", "\x00\x01\x1b\ufffe"},
+	{"invalid utf-8", "\xff\xfe\xc3\x28"},
+	{"", ""},
+}
+
+func FuzzEncodeXML(f *testing.F) {
+	for _, seed := range encoderFuzzSeeds {
+		f.Add(seed[0], seed[1])
+	}
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		buff := bytes.NewBuffer(nil)
+		err := XMLEncoder.Encode(buff, Report{Payload: map[string]string{key: value, "static": "OK"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		statuses := XMLStatuses{}
+		err = xml.Unmarshal(buff.Bytes(), &statuses)
+		if err != nil {
+			t.Fatalf("malformed XML %q: %v", buff.String(), err)
+		}
+		if len(statuses.Statuses) != 2 && key != "static" {
+			t.Fatalf("expected 2 statuses, got %d: %q", len(statuses.Statuses), buff.String())
+		}
+
+		if !utf8.ValidString(key) || !utf8.ValidString(value) || !isXMLText(key+value) {
+			return
+		}
+		for _, status := range statuses.Statuses {
+			if status.Name == key && status.Value != value {
+				t.Fatalf("value mismatch, expected %q, got %q", value, status.Value)
+			}
+		}
+	})
+}
+
+func FuzzEncodeHTML(f *testing.F) {
+	for _, seed := range encoderFuzzSeeds {
+		f.Add(seed[0], seed[1])
+	}
+
+	f.Fuzz(func(t *testing.T, key, value string) {
+		buff := bytes.NewBuffer(nil)
+		err := HTMLEncoder.Encode(buff, Report{Payload: map[string]string{key: value}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every '<' in the output must be from the markup of the template, i.e. 4 for the
+		// table & tbody, and 6 per row
+		out := buff.String()
+		if count := strings.Count(out, "<"); count != 10 {
+			t.Fatalf("unescaped markup in %q", out)
+		}
+		if strings.ContainsAny(strings.NewReplacer(
+			"<table>", "", "</table>", "",
+			"<tbody>", "", "</tbody>", "",
+			"<tr>", "", "</tr>", "",
+			"<th>", "", "</th>", "",
+			"<td>", "", "</td>", "",
+		).Replace(out), `<>"'`) {
+			t.Fatalf("unescaped characters in %q", out)
+		}
+	})
+}
+
+// isXMLText reports whether all the characters are allowed in XML documents
+func isXMLText(s string) bool {
+	for _, r := range s {
+		if !(r == 0x09 || r == 0x0A || r == 0x0D ||
+			(r >= 0x20 && r <= 0xD7FF) ||
+			(r >= 0xE000 && r <= 0xFFFD) ||
+			(r >= 0x10000 && r <= 0x10FFFF)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+)
+
+const (
+	HTTPPathEvents = "/-/events"
+
+	// DefaultEventBacklog is the number of events retained for clients resuming the stream
+	DefaultEventBacklog = 256
+	// DefaultHeartbeat is the interval of heartbeats, sent to keep idle connections alive
+	DefaultHeartbeat = 15 * time.Second
+
+	// EventSnapshot is the name of the event with the full state, sent when the stream starts
+	// or cannot be resumed
+	EventSnapshot = "snapshot"
+
+	httpHeaderContentTypeEventStream = "text/event-stream"
+	httpHeaderLastEventID            = "Last-Event-ID"
+	httpHeaderCacheControl           = "Cache-Control"
+)
+
+// ChangeEvent is the data of the events of changes, the name of the event is the kind of
+// change e.g. "status", "payload-updated"
+type ChangeEvent struct {
+	proberesponder.Change
+	At time.Time `json:"at"`
+}
+
+type streamEvent struct {
+	id   uint64
+	name string
+	data []byte
+}
+
+func (se streamEvent) write(buff *bytes.Buffer) {
+	fmt.Fprintf(buff, "id: %d\nevent: %s\ndata: %s\n\n", se.id, se.name, se.data)
+}
+
+// eventStream maintains the backlog of events shared by all the clients of a handler
+type eventStream struct {
+	pres *proberesponder.ProbeResponder
+	hcfg *handlerConfig
+
+	locker  *sync.Mutex
+	backlog []streamEvent
+	lastID  uint64
+	// updated is closed, and replaced, every time new events are added to the backlog
+	updated chan struct{}
+}
+
+// WithEventBacklog sets the number of events retained by HTTPEvents, for clients resuming
+// the stream with the Last-Event-ID header
+func WithEventBacklog(size int) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.eventBacklog = size
+	}
+}
+
+// WithHeartbeat sets the interval of heartbeats sent by HTTPEvents
+func WithHeartbeat(interval time.Duration) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.heartbeat = interval
+	}
+}
+
+// HTTPEvents streams the changes of statuses & payload as Server-Sent Events. The stream
+// starts with a "snapshot" event having the full state (proberesponder.Snapshot), followed
+// by an event per change (ChangeEvent). Clients reconnecting with the Last-Event-ID header
+// are sent only the events missed, if they're still in the backlog, otherwise a new
+// snapshot. Heartbeats are sent as comments, at the configured interval. The events are
+// delivered at least once, i.e. a change may be included in the snapshot and be sent as
+// an event as well. Every call subscribes to pres for as long as pres is in use, so the
+// handler is meant to be created once and reused.
+func HTTPEvents(pres *proberesponder.ProbeResponder, opts ...HandlerOption) http.HandlerFunc {
+	hcfg := newHandlerConfig(opts...)
+	if hcfg.eventBacklog <= 0 {
+		hcfg.eventBacklog = DefaultEventBacklog
+	}
+	if hcfg.heartbeat <= 0 {
+		hcfg.heartbeat = DefaultHeartbeat
+	}
+
+	es := &eventStream{
+		pres:    pres,
+		hcfg:    hcfg,
+		locker:  &sync.Mutex{},
+		backlog: make([]streamEvent, 0, hcfg.eventBacklog),
+		updated: make(chan struct{}),
+	}
+	// the handler has no lifetime of its own to unsubscribe at, it lives as long as pres
+	_ = pres.Subscribe(es.record)
+
+	return es.serve
+}
+
+// record adds the changes to the backlog, and notifies the connected clients
+func (es *eventStream) record(changes []proberesponder.Change) {
+	now := time.Now()
+
+	es.locker.Lock()
+	defer es.locker.Unlock()
+
+	for _, ch := range changes {
+		data, err := json.Marshal(ChangeEvent{
+			Change: es.hcfg.redactor.redactChange(ch),
+			At:     now,
+		})
+		if err != nil {
+			continue
+		}
+
+		es.lastID++
+		es.backlog = append(es.backlog, streamEvent{id: es.lastID, name: ch.Kind.String(), data: data})
+	}
+
+	if excess := len(es.backlog) - es.hcfg.eventBacklog; excess > 0 {
+		es.backlog = append(es.backlog[:0], es.backlog[excess:]...)
+	}
+
+	close(es.updated)
+	es.updated = make(chan struct{})
+}
+
+// since returns the events after the ID, and ok as false if the ID is not within the backlog
+func (es *eventStream) since(id uint64) (events []streamEvent, ok bool) {
+	if id > es.lastID {
+		return nil, false
+	}
+	if id == es.lastID {
+		return nil, true
+	}
+	if len(es.backlog) == 0 || id+1 < es.backlog[0].id {
+		return nil, false
+	}
+
+	return append([]streamEvent(nil), es.backlog[id+1-es.backlog[0].id:]...), true
+}
+
+// start returns the events to be sent first to a client, along with the ID of the last event,
+// and the channel closed when events are added after it. Both are read under the same lock, so
+// that events added meanwhile are not missed.
+func (es *eventStream) start(r *http.Request) ([]streamEvent, uint64, <-chan struct{}) {
+	es.locker.Lock()
+	defer es.locker.Unlock()
+
+	if lastEventID := r.Header.Get(httpHeaderLastEventID); lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err == nil {
+			if events, ok := es.since(id); ok {
+				return events, es.lastID, es.updated
+			}
+		}
+	}
+
+	data, _ := json.Marshal(es.hcfg.redactor.redactSnapshot(es.pres.Snapshot()))
+	return []streamEvent{{id: es.lastID, name: EventSnapshot, data: data}}, es.lastID, es.updated
+}
+
+// next returns the events after the ID, along with the ID of the last event, and the channel
+// closed when events are added after it. ok is false if the ID is not within the backlog.
+func (es *eventStream) next(id uint64) (events []streamEvent, lastID uint64, updated <-chan struct{}, ok bool) {
+	es.locker.Lock()
+	defer es.locker.Unlock()
+
+	events, ok = es.since(id)
+	return events, es.lastID, es.updated, ok
+}
+
+func (es *eventStream) serve(w http.ResponseWriter, r *http.Request) {
+	if !es.hcfg.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// the stream is long lived, and must not be limited by the write timeout of the server
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set(httpHeaderContentType, httpHeaderContentTypeEventStream)
+	w.Header().Set(httpHeaderCacheControl, "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	events, lastID, updated := es.start(r)
+	heartbeat := time.NewTicker(es.hcfg.heartbeat)
+	defer heartbeat.Stop()
+
+	buff := bytes.NewBuffer(nil)
+	for {
+		buff.Reset()
+		for _, se := range events {
+			se.write(buff)
+		}
+		if buff.Len() > 0 && !es.flush(w, r, rc, buff.Bytes()) {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			events = nil
+			if !es.flush(w, r, rc, []byte(": heartbeat\n\n")) {
+				return
+			}
+		case <-updated:
+			var ok bool
+			events, lastID, updated, ok = es.next(lastID)
+			if !ok {
+				// the client fell behind by more than the backlog, it's disconnected so that
+				// it reconnects and starts with a new snapshot
+				return
+			}
+		}
+	}
+}
+
+// flush writes & flushes the payload, it returns false if the client can no longer be written to
+func (es *eventStream) flush(w http.ResponseWriter, r *http.Request, rc *http.ResponseController, payload []byte) bool {
+	_, err := w.Write(payload)
+	if err == nil {
+		err = rc.Flush()
+	}
+	if err != nil {
+		es.hcfg.logger.log().Log(
+			r.Context(),
+			logging.EventWriteFailure,
+			"failed to write event",
+			slog.String("path", r.URL.Path),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	return true
+}
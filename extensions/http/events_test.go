@@ -0,0 +1,215 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseMessage struct {
+	id      string
+	name    string
+	data    string
+	comment string
+}
+
+// readEvents reads the stream, sending every message to the channel till the stream ends
+func readEvents(t *testing.T, srv *httptest.Server, lastEventID string) (<-chan sseMessage, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+HTTPPathEvents, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		r.Header.Set(httpHeaderLastEventID, lastEventID)
+	}
+
+	resp, err := srv.Client().Do(r)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, httpHeaderContentTypeEventStream, resp.Header.Get(httpHeaderContentType))
+
+	messages := make(chan sseMessage, 64)
+	go func() {
+		defer close(messages)
+		defer resp.Body.Close()
+
+		msg := sseMessage{}
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				messages <- msg
+				msg = sseMessage{}
+			case strings.HasPrefix(line, ":"):
+				msg.comment = strings.TrimSpace(line[1:])
+			case strings.HasPrefix(line, "id: "):
+				msg.id = line[len("id: "):]
+			case strings.HasPrefix(line, "event: "):
+				msg.name = line[len("event: "):]
+			case strings.HasPrefix(line, "data: "):
+				msg.data = line[len("data: "):]
+			}
+		}
+	}()
+
+	return messages, cancel
+}
+
+func nextEvent(t *testing.T, messages <-chan sseMessage) sseMessage {
+	select {
+	case msg, ok := <-messages:
+		require.True(t, ok, "stream ended")
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return sseMessage{}
+}
+
+func TestHTTPEvents(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.AppendHealthResponse("mydb", "OK")
+	pRes.AppendHealthResponse("db_password", "hunter2")
+
+	srv := httptest.NewServer(HTTPEvents(
+		pRes,
+		WithEventBacklog(3),
+		WithRedactor(DefaultRedactor()),
+	))
+	defer srv.Close()
+
+	tt.Run("snapshot & changes", func(t *testing.T) {
+		asserter := assert.New(t)
+		messages, cancel := readEvents(t, srv, "")
+		defer cancel()
+
+		msg := nextEvent(t, messages)
+		asserter.Equal(EventSnapshot, msg.name)
+		asserter.Equal("0", msg.id)
+		snap := proberesponder.Snapshot{}
+		asserter.NoError(json.Unmarshal([]byte(msg.data), &snap))
+		asserter.Equal("OK", snap.Payload["mydb"].Value)
+		asserter.Equal(RedactedValue, snap.Payload["db_password"].Value)
+		asserter.True(snap.Statuses[proberesponder.StatusReady].NotOK)
+
+		pRes.SetStatus(proberesponder.StatusReady, false, "")
+		msg = nextEvent(t, messages)
+		asserter.Equal("1", msg.id)
+		asserter.Equal(proberesponder.ChangeStatus.String(), msg.name)
+		ce := ChangeEvent{}
+		asserter.NoError(json.Unmarshal([]byte(msg.data), &ce))
+		asserter.Equal("ready", ce.Key)
+		asserter.Equal("NOT OK", ce.From)
+		asserter.Equal("OK", ce.To)
+		asserter.False(ce.At.IsZero())
+
+		msg = nextEvent(t, messages)
+		asserter.Equal("2", msg.id)
+		asserter.Equal(proberesponder.ChangePayloadUpdated.String(), msg.name)
+
+		pRes.AppendHealthResponse("db_password", "hunter3")
+		msg = nextEvent(t, messages)
+		asserter.Equal("3", msg.id)
+		asserter.NoError(json.Unmarshal([]byte(msg.data), &ce))
+		asserter.Equal(RedactedValue, ce.To)
+	})
+
+	tt.Run("resume", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes.AppendHealthResponse("mydb", "NOT OK")
+
+		messages, cancel := readEvents(t, srv, "3")
+		defer cancel()
+
+		msg := nextEvent(t, messages)
+		asserter.Equal("4", msg.id)
+		asserter.Equal(proberesponder.ChangePayloadUpdated.String(), msg.name)
+	})
+
+	tt.Run("resume beyond backlog", func(t *testing.T) {
+		messages, cancel := readEvents(t, srv, "0")
+		defer cancel()
+
+		msg := nextEvent(t, messages)
+		assert.Equal(t, EventSnapshot, msg.name)
+		assert.Equal(t, "4", msg.id)
+	})
+
+	tt.Run("invalid last event ID", func(t *testing.T) {
+		messages, cancel := readEvents(t, srv, "100")
+		defer cancel()
+
+		assert.Equal(t, EventSnapshot, nextEvent(t, messages).name)
+	})
+}
+
+func TestHTTPEvents_Heartbeat(t *testing.T) {
+	srv := httptest.NewServer(HTTPEvents(proberesponder.New(), WithHeartbeat(10*time.Millisecond)))
+	defer srv.Close()
+
+	messages, cancel := readEvents(t, srv, "")
+	defer cancel()
+
+	assert.Equal(t, EventSnapshot, nextEvent(t, messages).name)
+	assert.Equal(t, "heartbeat", nextEvent(t, messages).comment)
+}
+
+func TestHTTPEvents_Unauthorized(t *testing.T) {
+	w := httptest.NewRecorder()
+	handler := HTTPEvents(proberesponder.New(), WithAuthorizer(BearerToken("ops", "s3cr3t")))
+	handler(w, httptest.NewRequest(http.MethodGet, HTTPPathEvents, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+}
+
+func TestEventStream_NoLostWakeup(tt *testing.T) {
+	pRes := proberesponder.New()
+	newStream := func() *eventStream {
+		return &eventStream{
+			pres:    pRes,
+			hcfg:    newHandlerConfig(WithEventBacklog(DefaultEventBacklog)),
+			locker:  &sync.Mutex{},
+			updated: make(chan struct{}),
+		}
+	}
+	changes := []proberesponder.Change{{Kind: proberesponder.ChangePayloadUpdated, Key: "mydb"}}
+
+	tt.Run("start", func(t *testing.T) {
+		stream := newStream()
+		_, lastID, updated := stream.start(httptest.NewRequest(http.MethodGet, HTTPPathEvents, nil))
+		stream.record(changes)
+
+		select {
+		case <-updated:
+		default:
+			t.Fatal("not notified of the events recorded after start")
+		}
+
+		events, _, _, ok := stream.next(lastID)
+		assert.True(t, ok)
+		assert.Len(t, events, 1)
+	})
+
+	tt.Run("next", func(t *testing.T) {
+		stream := newStream()
+		stream.record(changes)
+		_, _, updated, ok := stream.next(0)
+		assert.True(t, ok)
+		stream.record(changes)
+
+		select {
+		case <-updated:
+		default:
+			t.Fatal("not notified of the events recorded after next")
+		}
+	})
+}
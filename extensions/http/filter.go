@@ -0,0 +1,48 @@
+package http
+
+import (
+	"github.com/naughtygopher/proberesponder"
+)
+
+const queryParamAll = "all"
+
+// filterReport removes the entries which are not relevant to the status queried, i.e. the
+// other probe statuses, and dependency checks which do not affect the status. All other
+// entries of the payload are retained, since they are not owned by any of the statuses. Checks
+// which do not affect any status are informational, and are only included with "all".
+func filterReport(rep Report) Report {
+	irrelevant := map[string]bool{}
+	for _, status := range []proberesponder.Statuskey{
+		proberesponder.StatusStartup,
+		proberesponder.StatusReady,
+		proberesponder.StatusLive,
+	} {
+		if status != rep.Status {
+			irrelevant[status.PayloadKey()] = true
+		}
+	}
+
+	checks := make([]proberesponder.CheckResult, 0, len(rep.Checks))
+	for _, cr := range rep.Checks {
+		if !cr.Affects(rep.Status) {
+			irrelevant[cr.ID] = true
+			continue
+		}
+		checks = append(checks, cr)
+	}
+	rep.Checks = checks
+
+	payload := make(map[string]string, len(rep.Payload))
+	for key, value := range rep.Payload {
+		if !irrelevant[key] {
+			payload[key] = value
+		}
+	}
+	rep.Payload = payload
+
+	if ps, ok := rep.Probes[rep.Status]; ok {
+		rep.Probes = map[proberesponder.Statuskey]proberesponder.StatusSnapshot{rep.Status: ps}
+	}
+
+	return rep
+}
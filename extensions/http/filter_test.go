@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilteredPayload(t *testing.T) {
+	pRes := newResponderWithChecks(checkDBDown, checkLiveDn, proberesponder.CheckResult{ID: "unowned_check"})
+	pRes.AppendHealthResponse("version", "v1.2.3")
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		query    string
+		wantKeys []string
+	}{
+		{
+			name:     "startup",
+			handler:  HTTPStartup(pRes),
+			wantKeys: []string{"probe->startup", "version"},
+		},
+		{
+			name:     "ready",
+			handler:  HTTPReady(pRes),
+			wantKeys: []string{"probe->ready", "mydb", "version"},
+		},
+		{
+			name:     "live",
+			handler:  HTTPLive(pRes),
+			wantKeys: []string{"probe->live", "disk", "version"},
+		},
+		{
+			name:    "all",
+			handler: HTTPLive(pRes),
+			query:   "?all",
+			wantKeys: []string{
+				"probe->startup", "probe->ready", "probe->live",
+				"disk", "mydb", "unowned_check", "version",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			r.Header.Set(httpHeaderAccept, httpHeaderContentTypeJSON)
+			tt.handler(w, r)
+
+			payload := map[string]string{}
+			asserter.NoError(json.Unmarshal(w.Body.Bytes(), &payload))
+			asserter.Len(payload, len(tt.wantKeys))
+			for _, key := range tt.wantKeys {
+				asserter.Contains(payload, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+const (
+	httpHeaderRetryAfter = "Retry-After"
+
+	// DefaultRetryAfter is the value of the Retry-After header, responded by ReadinessGate
+	DefaultRetryAfter = 5 * time.Second
+)
+
+// GateOption configures the ReadinessGate middleware
+type GateOption func(gcfg *gateConfig)
+
+type gateConfig struct {
+	retryAfter time.Duration
+	allowed    []string
+}
+
+// WithRetryAfter sets the duration in the Retry-After header, instead of DefaultRetryAfter.
+// It is rounded up to seconds, and the header is not set if it's less than 1.
+func WithRetryAfter(retryAfter time.Duration) GateOption {
+	return func(gcfg *gateConfig) {
+		gcfg.retryAfter = retryAfter
+	}
+}
+
+// WithAllowedPaths lets the requests of the paths through, even when not ready. Similar to
+// http.ServeMux, a path ending with "/" allows all the paths under it, e.g. "/admin/".
+func WithAllowedPaths(paths ...string) GateOption {
+	return func(gcfg *gateConfig) {
+		gcfg.allowed = append(gcfg.allowed, paths...)
+	}
+}
+
+func (gcfg *gateConfig) isAllowed(path string) bool {
+	for _, allowed := range gcfg.allowed {
+		if path == allowed || (strings.HasSuffix(allowed, "/") && strings.HasPrefix(path, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadinessGate is a middleware for the app's handlers, which responds with 503 Service
+// Unavailable along with the Retry-After header, while the app is NOT ready. So the same
+// state which drives the readiness probe also protects the app, e.g. during warmup & drain.
+func ReadinessGate(pres *proberesponder.ProbeResponder, next http.Handler, opts ...GateOption) http.Handler {
+	gcfg := &gateConfig{
+		retryAfter: DefaultRetryAfter,
+	}
+	for _, opt := range opts {
+		opt(gcfg)
+	}
+
+	retryAfter := ""
+	if seconds := math.Ceil(gcfg.retryAfter.Seconds()); seconds >= 1 {
+		retryAfter = strconv.Itoa(int(seconds))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !pres.NotReady() || gcfg.isAllowed(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if retryAfter != "" {
+			w.Header().Set(httpHeaderRetryAfter, retryAfter)
+		}
+		w.Header().Set(httpHeaderContentType, httpHeaderContentTypePlain)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+	})
+}
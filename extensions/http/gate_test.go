@@ -0,0 +1,130 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessGate(tt *testing.T) {
+	app := respondWith("app")
+
+	tests := []struct {
+		name           string
+		notReady       bool
+		opts           []GateOption
+		path           string
+		wantStatus     int
+		wantBody       string
+		wantRetryAfter string
+	}{
+		{
+			name:       "ready",
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+			wantBody:   "app",
+		},
+		{
+			name:           "not ready",
+			notReady:       true,
+			path:           "/orders",
+			wantStatus:     http.StatusServiceUnavailable,
+			wantBody:       "Service Unavailable",
+			wantRetryAfter: "5",
+		},
+		{
+			name:           "custom retry after, rounded up",
+			notReady:       true,
+			opts:           []GateOption{WithRetryAfter(1500 * time.Millisecond)},
+			path:           "/orders",
+			wantStatus:     http.StatusServiceUnavailable,
+			wantBody:       "Service Unavailable",
+			wantRetryAfter: "2",
+		},
+		{
+			name:       "without retry after",
+			notReady:   true,
+			opts:       []GateOption{WithRetryAfter(0)},
+			path:       "/orders",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "Service Unavailable",
+		},
+		{
+			name:       "allowed path",
+			notReady:   true,
+			opts:       []GateOption{WithAllowedPaths("/metrics", "/admin/")},
+			path:       "/metrics",
+			wantStatus: http.StatusOK,
+			wantBody:   "app",
+		},
+		{
+			name:       "allowed subtree",
+			notReady:   true,
+			opts:       []GateOption{WithAllowedPaths("/metrics", "/admin/")},
+			path:       "/admin/users",
+			wantStatus: http.StatusOK,
+			wantBody:   "app",
+		},
+		{
+			name:           "exact paths do not allow subtree",
+			notReady:       true,
+			opts:           []GateOption{WithAllowedPaths("/metrics")},
+			path:           "/metrics/raw",
+			wantStatus:     http.StatusServiceUnavailable,
+			wantBody:       "Service Unavailable",
+			wantRetryAfter: "5",
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			pRes := proberesponder.New()
+			pRes.SetNotReady(tc.notReady)
+
+			w := httptest.NewRecorder()
+			ReadinessGate(pRes, app, tc.opts...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			asserter.Equal(tc.wantStatus, w.Result().StatusCode)
+			asserter.Equal(tc.wantBody, w.Body.String())
+			asserter.Equal(tc.wantRetryAfter, w.Header().Get(httpHeaderRetryAfter))
+		})
+	}
+}
+
+func TestReadinessGate_Concurrent(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := proberesponder.New()
+	gate := ReadinessGate(pRes, respondWith("app"))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			pRes.SetNotReady(i%2 == 0)
+		}
+		pRes.SetNotReady(false)
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				gate.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				asserter.Contains([]int{http.StatusOK, http.StatusServiceUnavailable}, w.Result().StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	gate.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	asserter.Equal(http.StatusOK, w.Result().StatusCode)
+}
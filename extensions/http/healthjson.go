@@ -0,0 +1,146 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/metadata"
+)
+
+const (
+	httpHeaderContentTypeHealthJSON = "application/health+json"
+
+	HealthPass = "pass"
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+// HealthJSON is the response as per the IETF draft "Health Check Response Format for
+// HTTP APIs" (draft-inadarei-api-health-check)
+type HealthJSON struct {
+	Status    string                       `json:"status"`
+	ReleaseID string                       `json:"releaseId,omitempty"`
+	ServiceID string                       `json:"serviceId,omitempty"`
+	Notes     []string                     `json:"notes,omitempty"`
+	Output    string                       `json:"output,omitempty"`
+	Checks    map[string][]HealthJSONCheck `json:"checks,omitempty"`
+}
+
+// HealthJSONCheck is a single entry of the checks in HealthJSON. The probe statuses are listed
+// with the key "<status>:status" (e.g. "ready:status"), and dependency checks with the key
+// "<ID>:responseTime".
+type HealthJSONCheck struct {
+	ComponentID   string   `json:"componentId,omitempty"`
+	ComponentType string   `json:"componentType,omitempty"`
+	ObservedValue *float64 `json:"observedValue,omitempty"`
+	ObservedUnit  string   `json:"observedUnit,omitempty"`
+	Status        string   `json:"status"`
+	Time          string   `json:"time,omitempty"`
+	Output        string   `json:"output,omitempty"`
+}
+
+// NewHealthJSONEncoder returns an encoder for application/health+json, with the given service &
+// release IDs
+func NewHealthJSONEncoder(serviceID, releaseID string) Encoder {
+	return NewEncoder(httpHeaderContentTypeHealthJSON, func(w io.Writer, rep Report) error {
+		return json.NewEncoder(w).Encode(newHealthJSON(serviceID, releaseID, rep))
+	})
+}
+
+func defaultServiceIDs() (serviceID, releaseID string) {
+	info := metadata.Read()
+	releaseID = info.Version
+	if releaseID == "" || releaseID == "(devel)" {
+		releaseID = info.Revision
+	}
+	return info.Module, releaseID
+}
+
+func healthString(notOK bool) string {
+	if notOK {
+		return HealthFail
+	}
+	return HealthPass
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func newHealthJSON(serviceID, releaseID string, rep Report) HealthJSON {
+	hj := HealthJSON{
+		Status:    healthString(rep.NotOK),
+		ReleaseID: releaseID,
+		ServiceID: serviceID,
+		Checks:    make(map[string][]HealthJSONCheck, len(rep.Probes)+len(rep.Checks)),
+	}
+
+	if rep.NotOK {
+		hj.Output = rep.Probes[rep.Status].Reason
+	}
+
+	for _, status := range []proberesponder.Statuskey{
+		proberesponder.StatusStartup,
+		proberesponder.StatusReady,
+		proberesponder.StatusLive,
+	} {
+		ps, ok := rep.Probes[status]
+		if !ok {
+			continue
+		}
+		hj.Checks[status.String()+":status"] = []HealthJSONCheck{{
+			ComponentID:   status.String(),
+			ComponentType: "system",
+			Status:        healthString(ps.NotOK),
+			Time:          formatTime(ps.Since),
+			Output:        ps.Reason,
+		}}
+	}
+
+	checkIDs := make(map[string]bool, len(rep.Checks))
+	for _, cr := range rep.Checks {
+		checkIDs[cr.ID] = true
+		latency := float64(cr.Latency) / float64(time.Millisecond)
+		hj.Checks[cr.ID+":responseTime"] = []HealthJSONCheck{{
+			ComponentID:   cr.ID,
+			ComponentType: "component",
+			ObservedValue: &latency,
+			ObservedUnit:  "ms",
+			Status:        healthString(cr.NotOK),
+			Time:          formatTime(cr.CheckedAt),
+			Output:        cr.Error,
+		}}
+
+		if cr.NotOK && hj.Status == HealthPass {
+			hj.Status = HealthWarn
+		}
+	}
+
+	// all other entries of the payload, which are neither probes nor checks, are listed as notes
+	for _, entry := range rep.entries() {
+		if checkIDs[entry.Key] || isProbeKey(entry.Key) {
+			continue
+		}
+		hj.Notes = append(hj.Notes, entry.Key+": "+entry.Value)
+	}
+
+	if len(hj.Checks) == 0 {
+		hj.Checks = nil
+	}
+
+	return hj
+}
+
+func isProbeKey(key string) bool {
+	for _, pkey := range probeKeys {
+		if pkey == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHealthJSONResponder() *proberesponder.ProbeResponder {
+	pRes := proberesponder.New()
+	pRes.SetNotStarted(false)
+	pRes.SetNotLive(false)
+	pRes.SetCheckResult(proberesponder.CheckResult{
+		ID:               "mydb",
+		NotOK:            true,
+		Error:            "dial postgres://admin:secret@db:5432 refused",
+		AffectedStatuses: []proberesponder.Statuskey{proberesponder.StatusReady},
+		Latency:          time.Millisecond * 12,
+		CheckedAt:        time.Now(),
+	})
+	pRes.SetStatus(proberesponder.StatusReady, true, "mydb unavailable")
+	pRes.AppendHealthResponse("version", "v1.2.3")
+	return pRes
+}
+
+func TestHealthJSONEncoder(tt *testing.T) {
+	tt.Run("failing probe", func(t *testing.T) {
+		asserter := assert.New(t)
+		requirer := require.New(t)
+		w := httptest.NewRecorder()
+		HTTPReady(newHealthJSONResponder())(w, httpReq(httpHeaderContentTypeHealthJSON))
+
+		asserter.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+		asserter.Equal(httpHeaderContentTypeHealthJSON, w.Header().Get(httpHeaderContentType))
+
+		hj := HealthJSON{}
+		requirer.NoError(json.Unmarshal(w.Body.Bytes(), &hj))
+		asserter.Equal(HealthFail, hj.Status)
+		asserter.Equal("mydb unavailable", hj.Output)
+		asserter.Equal([]string{"version: v1.2.3"}, hj.Notes)
+		asserter.Len(hj.Checks, 2)
+
+		ready := hj.Checks["ready:status"]
+		requirer.Len(ready, 1)
+		asserter.Equal("ready", ready[0].ComponentID)
+		asserter.Equal(HealthFail, ready[0].Status)
+		asserter.NotEmpty(ready[0].Time)
+
+		mydb := hj.Checks["mydb:responseTime"]
+		requirer.Len(mydb, 1)
+		asserter.Equal("mydb", mydb[0].ComponentID)
+		asserter.Equal(HealthFail, mydb[0].Status)
+		asserter.Equal("ms", mydb[0].ObservedUnit)
+		requirer.NotNil(mydb[0].ObservedValue)
+		asserter.Equal(12.0, *mydb[0].ObservedValue)
+		asserter.Contains(mydb[0].Output, "refused")
+	})
+
+	tt.Run("passing probe with failing dependency", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, HTTPPathLive+"?all", nil)
+		r.Header.Set(httpHeaderAccept, httpHeaderContentTypeHealthJSON)
+		HTTPLive(newHealthJSONResponder())(w, r)
+
+		hj := HealthJSON{}
+		asserter.NoError(json.Unmarshal(w.Body.Bytes(), &hj))
+		asserter.Equal(http.StatusOK, w.Result().StatusCode)
+		asserter.Equal(HealthWarn, hj.Status)
+		asserter.Empty(hj.Output)
+		asserter.Len(hj.Checks, 4)
+		asserter.Equal(HealthPass, hj.Checks["live:status"][0].Status)
+	})
+
+	tt.Run("passing probe with irrelevant failing dependency", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPLive(newHealthJSONResponder())(w, httpReq(httpHeaderContentTypeHealthJSON))
+
+		hj := HealthJSON{}
+		asserter.NoError(json.Unmarshal(w.Body.Bytes(), &hj))
+		asserter.Equal(HealthPass, hj.Status)
+		asserter.Len(hj.Checks, 1)
+	})
+
+	tt.Run("redacted", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPReady(
+			newHealthJSONResponder(),
+			WithRedactor(DefaultRedactor()),
+		)(w, httpReq(httpHeaderContentTypeHealthJSON))
+
+		hj := HealthJSON{}
+		asserter.NoError(json.Unmarshal(w.Body.Bytes(), &hj))
+		asserter.Equal("dial postgres://db:5432 refused", hj.Checks["mydb:responseTime"][0].Output)
+	})
+
+	tt.Run("custom service IDs", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		reg := NewEncoderRegistry(NewHealthJSONEncoder("payments", "2025.01.09"))
+		HTTPInfo(WithEncoderRegistry(reg))(w, httpReq(""))
+
+		hj := HealthJSON{}
+		asserter.NoError(json.Unmarshal(w.Body.Bytes(), &hj))
+		asserter.Equal(HealthPass, hj.Status)
+		asserter.Equal("payments", hj.ServiceID)
+		asserter.Equal("2025.01.09", hj.ReleaseID)
+		asserter.Nil(hj.Checks)
+		asserter.NotEmpty(hj.Notes)
+	})
+}
@@ -2,15 +2,15 @@ package http
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/naughtygopher/proberesponder/extensions/metadata"
 )
 
 const (
@@ -24,182 +24,349 @@ const (
 	HTTPPathStartup = "/-/startup"
 	HTTPPathReady   = "/-/ready"
 	HTTPPathLive    = "/-/live"
+	HTTPPathInfo    = "/-/info"
 )
 
 var (
-	acceptedContentTypes = strings.Join([]string{
-		httpHeaderContentTypeHTML,
-		httpHeaderContentTypePlain,
-		httpHeaderContentTypeJSON,
-	}, ",")
+	pkgLogger = atomic.Pointer[logging.Logger]{}
 )
 
+func init() {
+	pkgLogger.Store(logging.New(nil, nil))
+}
+
+// SetLogger sets the default logger used by the HTTP handlers, server & shutdown. By default
+// slog.Default() is used, with the default levels. A nil logger disables logging. The logger
+// can be set per handler or server instead, with WithLogger.
+func SetLogger(lg *logging.Logger) {
+	pkgLogger.Store(lg)
+}
+
+// loggerOption is the logger configured for a handler, server or shutdown. The package logger
+// is used if it's not set.
+type loggerOption struct {
+	logger *logging.Logger
+	set    bool
+}
+
+func (lo loggerOption) log() *logging.Logger {
+	if lo.set {
+		return lo.logger
+	}
+	return pkgLogger.Load()
+}
+
+// Handler is a route of the server. The handler is called for requests of the path with the
+// method. Multiple methods can be allowed for a path by adding a Handler per method, with the
+// same path. HEAD is allowed for all the paths allowing GET.
 type Handler struct {
 	Method  string
 	Path    string
 	Handler http.HandlerFunc
 }
 
-func HTTPStartup(pres *proberesponder.ProbeResponder) http.HandlerFunc {
+// HandlerOption configures the probe handlers, and is set per endpoint. e.g. the same
+// ProbeResponder can be served with full details internally, and redacted publicly.
+type HandlerOption func(hcfg *handlerConfig)
 
-	return func(w http.ResponseWriter, r *http.Request) {
-		status := http.StatusOK
-		if pres.NotStarted() {
-			status = http.StatusServiceUnavailable
+type handlerConfig struct {
+	redactor      *Redactor
+	notAcceptable bool
+	encoders      *EncoderRegistry
+	ordering      Ordering
+	authorizer    Authorizer
+	eventBacklog  int
+	heartbeat     time.Duration
+	refresh       time.Duration
+	eventsPath    string
+	statusCodes   map[ProbeState]int
+	minimalBodies map[ProbeState]string
+	cache         *responseCache
+	// compressionThreshold is the size of the response below which it's not compressed
+	compressionThreshold int
+	// dynamicRefresh is the duration for which a response with values computed on read is cached
+	dynamicRefresh time.Duration
+	logger         loggerOption
+}
+
+func newHandlerConfig(opts ...HandlerOption) *handlerConfig {
+	hcfg := &handlerConfig{
+		encoders: DefaultEncoderRegistry,
+		cache:    newResponseCache(),
+
+		compressionThreshold: DefaultCompressionThreshold,
+		dynamicRefresh:       DefaultDynamicRefresh,
+	}
+	for _, opt := range opts {
+		opt(hcfg)
+	}
+	return hcfg
+}
+
+// WithNotAcceptable responds with 406 Not Acceptable if none of the supported content types
+// are acceptable as per the Accept header. By default, such requests are responded with JSON.
+func WithNotAcceptable() HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.notAcceptable = true
+	}
+}
+
+// WithEncoderRegistry sets the registry of encoders used for content negotiation, instead
+// of DefaultEncoderRegistry
+func WithEncoderRegistry(reg *EncoderRegistry) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		if reg != nil {
+			hcfg.encoders = reg
 		}
-		respond(pres, w, r, status)
 	}
 }
 
-func HTTPReady(pres *proberesponder.ProbeResponder) http.HandlerFunc {
+// WithLogger sets the logger of the handler, instead of the one set using SetLogger. A nil
+// logger disables logging. When set for a server using WithHandlerOptions, it is used by all
+// the handlers of the server, and for logging the TLS certificate reload failures.
+func WithLogger(lg *logging.Logger) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.logger = loggerOption{logger: lg, set: true}
+	}
+}
 
+// WithRedactor sets the redactor applied to the health response, before it is encoded
+func WithRedactor(rd *Redactor) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.redactor = rd
+	}
+}
+
+func HTTPStartup(pres *proberesponder.ProbeResponder, opts ...HandlerOption) http.HandlerFunc {
+	hcfg := newHandlerConfig(opts...)
 	return func(w http.ResponseWriter, r *http.Request) {
-		status := http.StatusOK
-		if pres.NotReady() {
-			status = http.StatusServiceUnavailable
-		}
-		respond(pres, w, r, status)
+		hcfg.respondProbe(w, r, pres, proberesponder.StatusStartup)
 	}
 }
 
-func HTTPLive(pres *proberesponder.ProbeResponder) http.HandlerFunc {
+func HTTPReady(pres *proberesponder.ProbeResponder, opts ...HandlerOption) http.HandlerFunc {
+	hcfg := newHandlerConfig(opts...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		hcfg.respondProbe(w, r, pres, proberesponder.StatusReady)
+	}
+}
+
+func HTTPLive(pres *proberesponder.ProbeResponder, opts ...HandlerOption) http.HandlerFunc {
+	hcfg := newHandlerConfig(opts...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		hcfg.respondProbe(w, r, pres, proberesponder.StatusLive)
+	}
+}
 
+// HTTPInfo responds with the build and runtime metadata of the application
+func HTTPInfo(opts ...HandlerOption) http.HandlerFunc {
+	hcfg := newHandlerConfig(opts...)
 	return func(w http.ResponseWriter, r *http.Request) {
-		status := http.StatusOK
-		if pres.NotLive() {
-			status = http.StatusServiceUnavailable
+		if !hcfg.authorized(r) {
+			hcfg.respondUnauthorized(w, r)
+			return
 		}
-		respond(pres, w, r, status)
+		hcfg.respond(w, r, http.StatusOK, Report{Payload: metadata.Read().Map()}, nil)
 	}
 }
 
-func respond(
-	pres *proberesponder.ProbeResponder,
+// respondProbe responds with the probeStatus. The query parameter "exclude" excludes the
+// respective checks from affecting the status, and "verbose" responds with the result of each
+// check in plain text. The status code is as per the state of the probe (WithStatusCode). Only
+// the entries relevant to the status are included, unless the query parameter "all" is set.
+// Unauthorized requests are responded with only the status. The encoded responses are cached
+// per version of the state, and responded with an ETag.
+func (hcfg *handlerConfig) respondProbe(
 	w http.ResponseWriter,
 	r *http.Request,
-	status int,
+	pres *proberesponder.ProbeResponder,
+	probeStatus proberesponder.Statuskey,
 ) {
-	contentType, bPayload := contentNeogiater(r, pres.HealthResponse())
-	w.Header().Add(httpHeaderAccept, acceptedContentTypes)
-	w.Header().Add(httpHeaderContentType, contentType)
-	w.WriteHeader(status)
-	_, err := w.Write(bPayload)
-	if err != nil {
-		log.Println("failed to write response", err)
+	// version is read before the state, so that the state is never older than the version
+	version, static := pres.Version()
+
+	query := r.URL.Query()
+	excluded := excludedChecks(query)
+	probes := pres.Statuses()
+	checks := pres.CheckResults()
+	notOK := effectiveNotOK(probeStatus, probes[probeStatus], checks, excluded)
+	state := probeState(notOK, checks)
+	status := hcfg.statusCode(state)
+
+	if !hcfg.authorized(r) {
+		hcfg.respondBare(w, r, status, notOK)
+		return
 	}
-}
 
-func contentNeogiater(r *http.Request, payload map[string]string) (cType string, bPayload []byte) {
-	ctypes := strings.Split(r.Header.Get(httpHeaderAccept), ",")
-	maxQfactor := 0.0
+	_, verbose := query[queryParamVerbose]
+	if !verbose && hcfg.respondMinimal(w, r, state) {
+		return
+	}
 
-	for _, ct := range ctypes {
-		qFactor := 0.0
-		for _, part := range strings.Split(ct, ";") {
-			part = strings.TrimSpace(part)
-			if strings.Contains(part, "q=") || strings.Contains(part, "Q=") {
-				qFactor, _ = strconv.ParseFloat(strings.Split(part, "=")[1], 32)
-				if qFactor > 1.00 || qFactor < 0 {
-					qFactor = 0
-				}
-			}
-		}
+	_, all := query[queryParamAll]
+	if verbose {
+		rep := hcfg.probeReport(pres, probeStatus, notOK, probes, checks, all)
+		hcfg.respondVerbose(w, r, status, hcfg.redactor.redactReport(rep), excluded)
+		return
+	}
+
+	enc, ok := hcfg.negotiate(w, r)
+	if !ok {
+		return
+	}
 
-		if cType == "" || qFactor > maxQfactor {
-			maxQfactor = qFactor
-			cType = ct
+	// responses with checks excluded are not cached, to keep the number of variants bounded.
+	// Responses with values computed on read are cached only for the refresh duration.
+	cacheable := len(excluded) == 0 && (static || hcfg.dynamicRefresh > 0)
+	encoding := hcfg.contentEncoding(r)
+	key := cacheKey{mediaType: enc.MediaType(), encoding: encoding, all: all}
+	if cacheable {
+		cr, ok := hcfg.cache.get(version, key)
+		if ok && (static || time.Since(cr.cachedAt) < hcfg.dynamicRefresh) {
+			hcfg.writeEncoded(w, r, status, enc.MediaType(), cr)
+			return
 		}
 	}
 
-	if strings.Contains(cType, httpHeaderContentTypeHTML) {
-		cType = httpHeaderContentTypeHTML
-		bPayload = responseAsHTML(payload)
-	} else if strings.Contains(cType, httpHeaderContentTypePlain) {
-		cType = httpHeaderContentTypePlain
-		bPayload = responseAsPlainText(payload)
-	} else if strings.Contains(cType, httpHeaderContentTypeXML) {
-		cType = httpHeaderContentTypeXML
-		bPayload = responseAsXML(payload)
-	} else {
-		cType = httpHeaderContentTypeJSON
-		bPayload, _ = json.Marshal(payload)
+	rep := hcfg.probeReport(pres, probeStatus, notOK, probes, checks, all)
+	body, ok := hcfg.encode(w, r, enc, rep, pres.HealthResponseKeys())
+	if !ok {
+		return
 	}
 
-	return cType, bPayload
+	cr := hcfg.compressResponse(cachedResponse{body: body, etag: etag(status, body)}, encoding)
+	if cacheable {
+		cr.cachedAt = time.Now()
+		hcfg.cache.put(version, key, cr)
+	}
+	hcfg.writeEncoded(w, r, status, enc.MediaType(), cr)
 }
 
-func responseAsHTML(payload map[string]string) []byte {
-	buff := bytes.NewBufferString(
-		`<table><tbody>`,
-	)
-	for key, value := range payload {
-		buff.WriteString(`<tr>` +
-			`<th>` + key + `</th>` +
-			`<td>` + value + `</td>` +
-			`</tr>`)
+func (hcfg *handlerConfig) probeReport(
+	pres *proberesponder.ProbeResponder,
+	probeStatus proberesponder.Statuskey,
+	notOK bool,
+	probes map[proberesponder.Statuskey]proberesponder.StatusSnapshot,
+	checks []proberesponder.CheckResult,
+	all bool,
+) Report {
+	rep := Report{
+		Status:  probeStatus,
+		NotOK:   notOK,
+		Payload: pres.HealthResponse(),
+		Probes:  probes,
+		Checks:  checks,
 	}
-	buff.WriteString(`</tbody></table>`)
-	return buff.Bytes()
+	if !all {
+		rep = filterReport(rep)
+	}
+	return rep
 }
 
-func responseAsPlainText(payload map[string]string) []byte {
-	buff := bytes.NewBuffer([]byte{})
-	for key, value := range payload {
-		buff.WriteString(fmt.Sprintf("%s: %s | ", key, value))
+func (hcfg *handlerConfig) respond(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	rep Report,
+	registeredKeys []string,
+) {
+	enc, ok := hcfg.negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	body, ok := hcfg.encode(w, r, enc, rep, registeredKeys)
+	if !ok {
+		return
 	}
-	return buff.Bytes()
+
+	cr := hcfg.compressResponse(cachedResponse{body: body, etag: etag(status, body)}, hcfg.contentEncoding(r))
+	hcfg.writeEncoded(w, r, status, enc.MediaType(), cr)
 }
 
-func responseAsXML(payload map[string]string) []byte {
-	buff := bytes.NewBufferString(
-		`<statuses>`,
-	)
-	for key, value := range payload {
-		buff.WriteString(`<status name="` + key + `" value="` + value + `"></status>`)
+// negotiate returns the encoder as per the Accept header. If none are acceptable, and the
+// handler is configured to respond with 406 Not Acceptable, it responds and returns false.
+func (hcfg *handlerConfig) negotiate(w http.ResponseWriter, r *http.Request) (Encoder, bool) {
+	w.Header().Add(httpHeaderAccept, strings.Join(hcfg.encoders.MediaTypes(), ","))
+
+	enc, acceptable := hcfg.encoders.Negotiate(acceptHeader(r))
+	if enc == nil || (!acceptable && hcfg.notAcceptable) {
+		w.Header().Add(httpHeaderContentType, httpHeaderContentTypePlain)
+		w.WriteHeader(http.StatusNotAcceptable)
+		hcfg.write(w, r, []byte("acceptable content types: "+w.Header().Get(httpHeaderAccept)))
+		return nil, false
 	}
-	buff.WriteString(`</statuses>`)
-	return buff.Bytes()
+
+	return enc, true
 }
 
-// Server is a basic/standard Golang HTTP server with the 3 default handlers for probes
-func Server(pres *proberesponder.ProbeResponder, host string, port uint16, handlers ...Handler) *http.Server {
-	smux := http.NewServeMux()
-	if len(handlers) == 0 {
-		handlers = []Handler{
-			{http.MethodGet, HTTPPathStartup, HTTPStartup(pres)},
-			{http.MethodGet, HTTPPathReady, HTTPReady(pres)},
-			{http.MethodGet, HTTPPathLive, HTTPLive(pres)},
-		}
-	} else {
-		handlers = append(handlers, []Handler{
-			{http.MethodGet, HTTPPathStartup, HTTPStartup(pres)},
-			{http.MethodGet, HTTPPathReady, HTTPReady(pres)},
-			{http.MethodGet, HTTPPathLive, HTTPLive(pres)},
-		}...)
-	}
-
-	for i := range handlers {
-		h := handlers[i]
-		smux.Handle(h.Path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != h.Method {
-				w.WriteHeader(http.StatusNotFound)
-				return
-			}
-			h.Handler(w, r)
-		}))
-	}
-
-	return &http.Server{
-		Addr:              fmt.Sprintf("%s:%d", host, port),
-		Handler:           smux,
-		ReadHeaderTimeout: time.Second,
-		ReadTimeout:       time.Second,
-		WriteTimeout:      time.Second * 5,
-		IdleTimeout:       time.Minute,
-	}
-}
-
-// StartHTTPServer directly initializes and starts a basic HTTP probe responder
-func StartHTTPServer(pres *proberesponder.ProbeResponder, host string, port uint16) error {
-	return Server(pres, host, port).ListenAndServe()
+// encode returns the redacted & ordered report encoded with enc. If it fails, it responds with
+// 500 Internal Server Error and returns false.
+func (hcfg *handlerConfig) encode(
+	w http.ResponseWriter,
+	r *http.Request,
+	enc Encoder,
+	rep Report,
+	registeredKeys []string,
+) ([]byte, bool) {
+	rep = hcfg.redactor.redactReport(rep)
+	rep.Entries = orderEntries(hcfg.ordering, rep.Payload, registeredKeys)
+	buff := bytes.NewBuffer(nil)
+	err := enc.Encode(buff, rep)
+	if err != nil {
+		hcfg.logger.log().Log(
+			r.Context(),
+			logging.EventEncodeFailure,
+			"failed to encode response",
+			slog.String("path", r.URL.Path),
+			slog.String("mediaType", enc.MediaType()),
+			slog.String("error", err.Error()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return buff.Bytes(), true
+}
+
+// writeEncoded responds with the encoded body along with its ETag & content encoding.
+// Successful responses are responded with 304 Not Modified if the ETag matches If-None-Match.
+func (hcfg *handlerConfig) writeEncoded(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	mediaType string,
+	cr cachedResponse,
+) {
+	// the body is negotiated with Accept, and with Accept-Encoding if compression is enabled
+	vary := httpHeaderAccept
+	if hcfg.compressionEnabled() {
+		vary += ", " + httpHeaderAcceptEncoding
+	}
+	w.Header().Add(httpHeaderVary, vary)
+	w.Header().Set(httpHeaderETag, cr.etag)
+	if status >= http.StatusOK && status < http.StatusMultipleChoices && notModified(r, cr.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Add(httpHeaderContentType, mediaType)
+	if cr.encoding != "" {
+		w.Header().Set(httpHeaderContentEncoding, cr.encoding)
+	}
+	w.WriteHeader(status)
+	hcfg.write(w, r, cr.body)
+}
+
+func (hcfg *handlerConfig) write(w http.ResponseWriter, r *http.Request, bPayload []byte) {
+	_, err := w.Write(bPayload)
+	if err != nil {
+		hcfg.logger.log().Log(
+			r.Context(),
+			logging.EventWriteFailure,
+			"failed to write response",
+			slog.String("path", r.URL.Path),
+			slog.String("error", err.Error()),
+		)
+	}
 }
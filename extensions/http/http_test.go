@@ -1,13 +1,19 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 
 	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/naughtygopher/proberesponder/extensions/metadata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -80,7 +86,7 @@ func TestHTTPStartup(tt *testing.T) {
 
 		text := w.Body.String()
 		asserter.Contains(text, "<table><tbody>")
-		asserter.Contains(text, "probe->startup")
+		asserter.Contains(text, "probe-&gt;startup")
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 	})
 
@@ -98,7 +104,7 @@ func TestHTTPStartup(tt *testing.T) {
 		payload := map[string]string{}
 		jbytes := w.Body.Bytes()
 		asserter.NoError(json.Unmarshal(jbytes, &payload))
-		asserter.Len(payload, 3)
+		asserter.Len(payload, 1)
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 		asserter.Contains(payload["probe->startup"], "OK:")
 	})
@@ -172,7 +178,7 @@ func TestHTTPReady(tt *testing.T) {
 
 		text := w.Body.String()
 		asserter.Contains(text, "<table><tbody>")
-		asserter.Contains(text, "probe->ready")
+		asserter.Contains(text, "probe-&gt;ready")
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 	})
 
@@ -190,7 +196,7 @@ func TestHTTPReady(tt *testing.T) {
 		payload := map[string]string{}
 		jbytes := w.Body.Bytes()
 		asserter.NoError(json.Unmarshal(jbytes, &payload))
-		asserter.Len(payload, 3)
+		asserter.Len(payload, 1)
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 		asserter.Contains(payload["probe->ready"], "OK:")
 	})
@@ -264,7 +270,7 @@ func TestHTTPLive(tt *testing.T) {
 
 		text := w.Body.String()
 		asserter.Contains(text, "<table><tbody>")
-		asserter.Contains(text, "probe->live")
+		asserter.Contains(text, "probe-&gt;live")
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 	})
 
@@ -281,7 +287,7 @@ func TestHTTPLive(tt *testing.T) {
 
 		text := w.Body.String()
 		asserter.Contains(text, "<statuses>")
-		asserter.Contains(text, "probe->live")
+		asserter.Contains(text, "probe-&gt;live")
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 	})
 	tt.Run("Content negotiation: JSON", func(t *testing.T) {
@@ -298,94 +304,25 @@ func TestHTTPLive(tt *testing.T) {
 		payload := map[string]string{}
 		jbytes := w.Body.Bytes()
 		asserter.NoError(json.Unmarshal(jbytes, &payload))
-		asserter.Len(payload, 3)
+		asserter.Len(payload, 1)
 		asserter.Equal(http.StatusOK, w.Result().StatusCode)
 		asserter.Contains(payload["probe->live"], "OK:")
 	})
 }
 
-func Test_contentNeogiater(t *testing.T) {
-	type args struct {
-		r       *http.Request
-		payload map[string]string
-	}
-	tests := []struct {
-		name      string
-		args      args
-		wantCType string
-	}{
-		{
-			name: "empty accept header",
-			args: args{
-				r: httpReq(""),
-			},
-			wantCType: httpHeaderContentTypeJSON,
-		},
-		{
-			name: "accept plain text",
-			args: args{
-				r: httpReq(httpHeaderContentTypePlain),
-			},
-			wantCType: httpHeaderContentTypePlain,
-		},
-		{
-			name: "accept HTML",
-			args: args{
-				r: httpReq(httpHeaderContentTypeHTML),
-			},
-			wantCType: httpHeaderContentTypeHTML,
-		},
-		{
-			name: "accept XML",
-			args: args{
-				r: httpReq(httpHeaderContentTypeXML),
-			},
-			wantCType: httpHeaderContentTypeXML,
-		},
-		{
-			name: "accept JSON",
-			args: args{
-				r: httpReq(httpHeaderContentTypeJSON),
-			},
-			wantCType: httpHeaderContentTypeJSON,
-		},
-		{
-			name: "With Quality factor",
-			args: args{
-				r: httpReq("application/json;q=0.25,application/xml;q=0.5"),
-			},
-			wantCType: httpHeaderContentTypeXML,
-		},
-		{
-			name: "With Quality factor less than 0",
-			args: args{
-				r: httpReq("application/json;q=0.25,application/xml;q=-0.5"),
-			},
-			wantCType: httpHeaderContentTypeJSON,
-		},
-		{
-			name: "With Quality factor greater than 1",
-			args: args{
-				r: httpReq("application/json;q=0.25,application/xml;q=1.5"),
-			},
-			wantCType: httpHeaderContentTypeJSON,
-		},
-		{
-			name: "With high Quality factor first",
-			args: args{
-				r: httpReq("application/json;q=0.55,application/xml;q=0.455"),
-			},
-			wantCType: httpHeaderContentTypeJSON,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotCType, _ := contentNeogiater(tt.args.r, tt.args.payload)
-			if gotCType != tt.wantCType {
-				t.Errorf("contentNeogiater() gotCType = %v, want %v", gotCType, tt.wantCType)
-			}
-		})
-	}
+func TestHTTPInfo(t *testing.T) {
+	asserter := assert.New(t)
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, HTTPPathInfo, nil)
+	r.Header.Set(httpHeaderAccept, httpHeaderContentTypeJSON)
+	srv := Server(proberesponder.New(), "", 1234, Handler{http.MethodGet, HTTPPathInfo, HTTPInfo()})
+	srv.Handler.ServeHTTP(w, r)
+
+	payload := map[string]string{}
+	asserter.NoError(json.Unmarshal(w.Body.Bytes(), &payload))
+	asserter.Equal(http.StatusOK, w.Result().StatusCode)
+	asserter.Equal(runtime.Version(), payload[metadata.KeyGoVersion])
+	asserter.Contains(payload, metadata.KeyUptime)
 }
 
 func TestCustomHandler(tt *testing.T) {
@@ -407,10 +344,55 @@ func TestCustomHandler(tt *testing.T) {
 		w := httptest.NewRecorder()
 		srv.Handler.ServeHTTP(w, req)
 		assert.Equal(t, "", w.Body.String())
-		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Result().StatusCode)
+		assert.Equal(t, http.MethodPost, w.Header().Get(httpHeaderAllow))
 	})
 }
 
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (fw failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestSetLogger(t *testing.T) {
+	asserter := assert.New(t)
+	buff := bytes.NewBuffer(nil)
+	SetLogger(logging.New(slog.New(slog.NewTextHandler(buff, nil)), nil))
+	defer SetLogger(logging.New(nil, nil))
+
+	w := failingWriter{ResponseRecorder: httptest.NewRecorder()}
+	HTTPLive(proberesponder.New())(w, httpReq(httpHeaderContentTypeJSON))
+	asserter.Contains(buff.String(), "level=ERROR")
+	asserter.Contains(buff.String(), `error="connection reset"`)
+
+	buff.Reset()
+	SetLogger(nil)
+	HTTPLive(proberesponder.New())(w, httpReq(httpHeaderContentTypeJSON))
+	asserter.Empty(buff.String())
+}
+
+func TestWithLogger(t *testing.T) {
+	asserter := assert.New(t)
+	global := bytes.NewBuffer(nil)
+	SetLogger(logging.New(slog.New(slog.NewTextHandler(global, nil)), nil))
+	defer SetLogger(logging.New(nil, nil))
+
+	first := bytes.NewBuffer(nil)
+	second := bytes.NewBuffer(nil)
+	w := failingWriter{ResponseRecorder: httptest.NewRecorder()}
+
+	HTTPLive(proberesponder.New(), WithLogger(logging.New(slog.New(slog.NewTextHandler(first, nil)), nil)))(w, httpReq(httpHeaderContentTypeJSON))
+	HTTPLive(proberesponder.New(), WithLogger(logging.New(slog.New(slog.NewTextHandler(second, nil)), nil)))(w, httpReq(httpHeaderContentTypeJSON))
+	HTTPLive(proberesponder.New(), WithLogger(nil))(w, httpReq(httpHeaderContentTypeJSON))
+
+	asserter.Contains(first.String(), `error="connection reset"`)
+	asserter.Contains(second.String(), `error="connection reset"`)
+	asserter.Empty(global.String())
+}
+
 func httpReq(acceptType string) *http.Request {
 	req, _ := http.NewRequest(http.MethodGet, "http://localhost:1234", nil)
 	req.Header.Add(httpHeaderAccept, acceptType)
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+const (
+	// KeyInFlightPrefix is the prefix of the keys in the health response with the number of
+	// in-flight requests, e.g. "inflight->orders", and "inflight->total" for all the routes
+	KeyInFlightPrefix = "inflight->"
+	KeyInFlightTotal  = KeyInFlightPrefix + "total"
+
+	// ReasonOverloaded is the reason of ready being NOT OK, when the number of in-flight
+	// requests exceeds the concurrency limit
+	ReasonOverloaded = "overloaded"
+)
+
+// InFlightOption configures InFlight
+type InFlightOption func(inf *InFlight)
+
+// WithConcurrencyLimit sets ready as NOT OK while the in-flight requests across all the
+// routes exceed the limit, so that the load balancers stop sending new requests (load
+// shedding). Ready is held as NOT OK using ProbeResponder.Hold, and released once the requests
+// are within the limit, restoring the status set in the meantime. Load is not shed while ready
+// is already NOT OK, e.g. shutting down, so its reason is retained. It is shed on the next
+// request once ready is OK again, if the limit is still exceeded. A limit less than 1 disables
+// it.
+func WithConcurrencyLimit(limit int) InFlightOption {
+	return func(inf *InFlight) {
+		inf.limit = limit
+	}
+}
+
+// InFlight tracks the requests being processed by the app's handlers, per route. The counts
+// are included in the health response, evaluated every time the response is read.
+type InFlight struct {
+	pres  *proberesponder.ProbeResponder
+	limit int
+
+	locker *sync.Mutex
+	active map[string]int
+	total  int
+	// idle is closed when there are no in-flight requests, and replaced on the next request
+	idle chan struct{}
+	// release releases the hold of ready due to exceeding the limit, nil if not held. It is
+	// read with locker, and modified with both locker & shedLocker.
+	release func()
+	// shedLocker serializes holding & releasing ready due to the limit
+	shedLocker *sync.Mutex
+}
+
+// NewInFlight returns InFlight, which adds the total of in-flight requests to the health
+// response
+func NewInFlight(pres *proberesponder.ProbeResponder, opts ...InFlightOption) *InFlight {
+	idle := make(chan struct{})
+	close(idle)
+
+	inf := &InFlight{
+		pres:       pres,
+		locker:     &sync.Mutex{},
+		active:     map[string]int{},
+		idle:       idle,
+		shedLocker: &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(inf)
+	}
+
+	pres.AppendHealthResponseFunc(KeyInFlightTotal, func() string {
+		return strconv.Itoa(inf.Total())
+	})
+
+	return inf
+}
+
+// Middleware tracks the in-flight requests of next, as the route. The count of the route is
+// added to the health response with the key KeyInFlightPrefix + route.
+func (inf *InFlight) Middleware(route string, next http.Handler) http.Handler {
+	inf.pres.AppendHealthResponseFunc(KeyInFlightPrefix+route, func() string {
+		return strconv.Itoa(inf.Active(route))
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inf.add(route, 1)
+		defer inf.add(route, -1)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (inf *InFlight) add(route string, delta int) {
+	inf.locker.Lock()
+	inf.active[route] += delta
+	inf.total += delta
+	switch {
+	case inf.total == 0:
+		close(inf.idle)
+	case inf.total == 1 && delta > 0:
+		inf.idle = make(chan struct{})
+	}
+	shed := inf.exceededWithoutLock() != (inf.release != nil)
+	inf.locker.Unlock()
+
+	if shed {
+		inf.shed()
+	}
+}
+
+func (inf *InFlight) exceededWithoutLock() bool {
+	return inf.limit > 0 && inf.total > inf.limit
+}
+
+// shed holds ready as NOT OK if the limit is exceeded, or releases the hold otherwise. It's
+// repeated till the hold matches the latest count, since the count may change meanwhile.
+func (inf *InFlight) shed() {
+	inf.shedLocker.Lock()
+	defer inf.shedLocker.Unlock()
+
+	for {
+		inf.locker.Lock()
+		exceeded := inf.exceededWithoutLock()
+		total := inf.total
+		release := inf.release
+		inf.locker.Unlock()
+
+		switch {
+		case exceeded && release == nil:
+			// ready is already NOT OK (e.g. shutting down), which takes precedence
+			if inf.pres.NotReady() {
+				return
+			}
+			release = inf.pres.Hold(
+				proberesponder.StatusReady,
+				fmt.Sprintf("%s: %d in-flight requests, limit %d", ReasonOverloaded, total, inf.limit),
+			)
+		case !exceeded && release != nil:
+			release()
+			release = nil
+		default:
+			return
+		}
+
+		inf.locker.Lock()
+		inf.release = release
+		inf.locker.Unlock()
+	}
+}
+
+// Active returns the number of in-flight requests of the route
+func (inf *InFlight) Active(route string) int {
+	inf.locker.Lock()
+	defer inf.locker.Unlock()
+
+	return inf.active[route]
+}
+
+// Total returns the number of in-flight requests across all the routes
+func (inf *InFlight) Total() int {
+	inf.locker.Lock()
+	defer inf.locker.Unlock()
+
+	return inf.total
+}
+
+// WaitIdle blocks till there are no in-flight requests, or till ctx is done. It can be used
+// as a shutdown hook, to drain the in-flight requests. e.g.
+//
+//	WithShutdownHook("drain", 30*time.Second, inflight.WaitIdle)
+func (inf *InFlight) WaitIdle(ctx context.Context) error {
+	inf.locker.Lock()
+	idle := inf.idle
+	inf.locker.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+// blocking returns a handler which blocks till release is closed, started is notified of each
+// request being served
+func blocking(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+}
+
+func TestInFlight(tt *testing.T) {
+	tt.Run("counts per route", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		inf := NewInFlight(pRes)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		orders := inf.Middleware("orders", blocking(started, release))
+		users := inf.Middleware("users", blocking(started, release))
+
+		wg := sync.WaitGroup{}
+		for _, h := range []http.Handler{orders, orders, users} {
+			wg.Add(1)
+			go func(h http.Handler) {
+				defer wg.Done()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}(h)
+			<-started
+		}
+
+		asserter.Equal(2, inf.Active("orders"))
+		asserter.Equal(1, inf.Active("users"))
+		asserter.Equal(3, inf.Total())
+
+		payload := pRes.HealthResponse()
+		asserter.Equal("2", payload[KeyInFlightPrefix+"orders"])
+		asserter.Equal("1", payload[KeyInFlightPrefix+"users"])
+		asserter.Equal("3", payload[KeyInFlightTotal])
+
+		close(release)
+		wg.Wait()
+
+		payload = pRes.HealthResponse()
+		asserter.Equal("0", payload[KeyInFlightPrefix+"orders"])
+		asserter.Equal("0", payload[KeyInFlightTotal])
+	})
+
+	tt.Run("concurrency limit", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+		inf := NewInFlight(pRes, WithConcurrencyLimit(1))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		h := inf.Middleware("orders", blocking(started, release))
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			<-started
+		}
+
+		ready := pRes.Statuses()[proberesponder.StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("overloaded: 2 in-flight requests, limit 1", ready.Reason)
+
+		close(release)
+		wg.Wait()
+
+		ready = pRes.Statuses()[proberesponder.StatusReady]
+		asserter.False(ready.NotOK)
+		asserter.Empty(ready.Reason)
+	})
+
+	tt.Run("concurrency limit does not override ready set otherwise", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+		inf := NewInFlight(pRes, WithConcurrencyLimit(1))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		h := inf.Middleware("orders", blocking(started, release))
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			<-started
+		}
+
+		pRes.SetStatus(proberesponder.StatusReady, true, "shutting down")
+		close(release)
+		wg.Wait()
+
+		ready := pRes.Statuses()[proberesponder.StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("shutting down", ready.Reason)
+	})
+
+	tt.Run("concurrency limit does not override ready already not ok", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		pRes.SetStatus(proberesponder.StatusReady, true, "shutting down")
+		inf := NewInFlight(pRes, WithConcurrencyLimit(1))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		h := inf.Middleware("orders", blocking(started, release))
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			<-started
+		}
+
+		ready := pRes.Statuses()[proberesponder.StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("shutting down", ready.Reason)
+
+		close(release)
+		wg.Wait()
+
+		ready = pRes.Statuses()[proberesponder.StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("shutting down", ready.Reason)
+	})
+
+	tt.Run("concurrency limit sheds once ready is ok again", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		pRes.SetStatus(proberesponder.StatusReady, true, "mydb down")
+		inf := NewInFlight(pRes, WithConcurrencyLimit(1))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		h := inf.Middleware("orders", blocking(started, release))
+
+		wg := sync.WaitGroup{}
+		serve := func() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+			<-started
+		}
+		serve()
+		serve()
+		asserter.Equal("mydb down", pRes.Statuses()[proberesponder.StatusReady].Reason)
+
+		pRes.SetStatus(proberesponder.StatusReady, false, "")
+		serve()
+		ready := pRes.Statuses()[proberesponder.StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("overloaded: 3 in-flight requests, limit 1", ready.Reason)
+
+		close(release)
+		wg.Wait()
+
+		ready = pRes.Statuses()[proberesponder.StatusReady]
+		asserter.False(ready.NotOK)
+		asserter.Empty(ready.Reason)
+	})
+
+	tt.Run("wait idle", func(t *testing.T) {
+		asserter := assert.New(t)
+		inf := NewInFlight(proberesponder.New())
+		asserter.NoError(inf.WaitIdle(context.Background()))
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		h := inf.Middleware("orders", blocking(started, release))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		asserter.ErrorIs(inf.WaitIdle(ctx), context.DeadlineExceeded)
+
+		waited := make(chan error, 1)
+		go func() { waited <- inf.WaitIdle(context.Background()) }()
+		close(release)
+		<-done
+		asserter.NoError(<-waited)
+	})
+}
@@ -0,0 +1,222 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// mediaRange is a single element of the Accept header, as defined in RFC 9110, section 12.5.1
+type mediaRange struct {
+	mtype   string
+	subtype string
+	params  map[string]string
+	quality float64
+}
+
+// specificity ranks how specific the media range is, a more specific range takes precedence
+// over a less specific one matching the same media type
+func (mr mediaRange) specificity() int {
+	switch {
+	case mr.mtype == "*":
+		return 0
+	case mr.subtype == "*":
+		return 1
+	default:
+		return 2 + len(mr.params)
+	}
+}
+
+func (mr mediaRange) matches(offer mediaRange) bool {
+	if mr.mtype != "*" && mr.mtype != offer.mtype {
+		return false
+	}
+	if mr.subtype != "*" && mr.subtype != offer.subtype {
+		return false
+	}
+
+	for key, value := range mr.params {
+		ovalue, ok := offer.params[key]
+		if !ok && key == "charset" {
+			// all the responses are encoded as UTF-8
+			ovalue, ok = "utf-8", true
+		}
+		if !ok || !strings.EqualFold(value, ovalue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// acceptHeader returns all values of the Accept header, combined into a single list
+func acceptHeader(r *http.Request) string {
+	return strings.Join(r.Header.Values(httpHeaderAccept), ",")
+}
+
+// negotiate returns the offer most preferred by the Accept header. For each offer, the quality
+// of the most specific matching media range is used. Ties are broken by the specificity of the
+// matching range, and then by the order of offers. ok is false if none of the offers are
+// acceptable. An empty Accept header accepts the first offer.
+func negotiate(accept string, offers []string) (offer string, ok bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := parseAccept(accept)
+	bestQuality := 0.0
+	bestSpecificity := -1
+
+	for _, ofr := range offers {
+		parsedOffer, valid := parseMediaRange(ofr)
+		if !valid {
+			continue
+		}
+
+		quality, specificity := 0.0, -1
+		for _, mr := range ranges {
+			if !mr.matches(parsedOffer) || mr.specificity() <= specificity {
+				continue
+			}
+			quality, specificity = mr.quality, mr.specificity()
+		}
+
+		if quality <= 0 {
+			continue
+		}
+
+		if quality > bestQuality || (quality == bestQuality && specificity > bestSpecificity) {
+			offer, bestQuality, bestSpecificity = ofr, quality, specificity
+		}
+	}
+
+	return offer, offer != ""
+}
+
+// parseAccept parses the Accept header into media ranges. Invalid elements are ignored.
+func parseAccept(accept string) []mediaRange {
+	elements := splitQuoted(accept, ',')
+	ranges := make([]mediaRange, 0, len(elements))
+	for _, elem := range elements {
+		mr, valid := parseMediaRange(elem)
+		if !valid {
+			continue
+		}
+		ranges = append(ranges, mr)
+	}
+
+	return ranges
+}
+
+// parseMediaRange parses a media range e.g. `text/plain;charset="utf-8";q=0.5`. Parameters
+// after the weight are accept-extensions, and are ignored.
+func parseMediaRange(elem string) (mediaRange, bool) {
+	parts := splitQuoted(elem, ';')
+	if len(parts) == 0 {
+		return mediaRange{}, false
+	}
+
+	mtype, subtype, found := strings.Cut(strings.ToLower(strings.TrimSpace(parts[0])), "/")
+	if !found || !isToken(mtype) || !isToken(subtype) || (mtype == "*" && subtype != "*") {
+		return mediaRange{}, false
+	}
+
+	mr := mediaRange{
+		mtype:   mtype,
+		subtype: subtype,
+		params:  map[string]string{},
+		quality: 1,
+	}
+
+	for _, param := range parts[1:] {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !found || !isToken(key) {
+			return mediaRange{}, false
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = strings.ReplaceAll(value[1:len(value)-1], `\`, "")
+		}
+
+		if key == "q" {
+			quality, valid := parseQuality(value)
+			if !valid {
+				return mediaRange{}, false
+			}
+			mr.quality = quality
+			break
+		}
+
+		mr.params[key] = value
+	}
+
+	return mr, true
+}
+
+// parseQuality parses the weight, as defined in RFC 9110 section 12.4.2. i.e. a value
+// between 0 and 1, with up to 3 decimal places
+func parseQuality(value string) (float64, bool) {
+	if value == "" || len(value) > 5 || (value[0] != '0' && value[0] != '1') {
+		return 0, false
+	}
+
+	if len(value) > 1 {
+		if value[1] != '.' {
+			return 0, false
+		}
+		for _, c := range value[2:] {
+			if c < '0' || c > '9' {
+				return 0, false
+			}
+		}
+	}
+
+	quality, err := strconv.ParseFloat(value, 64)
+	if err != nil || quality > 1 {
+		return 0, false
+	}
+
+	return quality, true
+}
+
+// splitQuoted splits s by sep, except when sep is within a quoted string
+func splitQuoted(s string, sep byte) []string {
+	parts := make([]string, 0, 4)
+	quoted, escaped := false, false
+	start := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			quoted = !quoted
+		case !quoted && s[i] == sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// isToken reports whether s is a valid token, as defined in RFC 9110 section 5.6.2
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > 0x7e || c <= 0x20 || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return true
+}
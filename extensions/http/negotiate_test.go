@@ -0,0 +1,304 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_negotiate(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		offers []string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "empty accept header",
+			accept: "",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "accept plain text",
+			accept: httpHeaderContentTypePlain,
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "accept HTML",
+			accept: httpHeaderContentTypeHTML,
+			want:   httpHeaderContentTypeHTML,
+			wantOK: true,
+		},
+		{
+			name:   "accept XML",
+			accept: httpHeaderContentTypeXML,
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "accept JSON",
+			accept: httpHeaderContentTypeJSON,
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "with quality factor",
+			accept: "application/json;q=0.25,application/xml;q=0.5",
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "with quality factor less than 0",
+			accept: "application/json;q=0.25,application/xml;q=-0.5",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "with quality factor greater than 1",
+			accept: "application/json;q=0.25,application/xml;q=1.5",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "with high quality factor first",
+			accept: "application/json;q=0.55,application/xml;q=0.455",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "unspecified quality defaults to 1",
+			accept: "text/*;q=0.9, application/json",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "subtype wildcard",
+			accept: "text/*",
+			want:   httpHeaderContentTypeHTML,
+			wantOK: true,
+		},
+		{
+			name:   "type wildcard uses server preference",
+			accept: "*/*",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "explicit type preferred over wildcard of equal quality",
+			accept: "*/*, text/plain",
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "more specific range overrides wildcard quality",
+			accept: "text/*;q=0.8, text/html;q=0.1",
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "quality 0 excludes a type",
+			accept: "application/json;q=0, */*;q=0.1",
+			want:   httpHeaderContentTypeHTML,
+			wantOK: true,
+		},
+		{
+			name:   "quality 0 excludes everything",
+			accept: "*/*;q=0",
+			wantOK: false,
+		},
+		{
+			name:   "application wildcard",
+			accept: "application/*;q=0.9, text/html;q=0.5",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "application wildcard with excluded JSON",
+			accept: "application/*, application/json;q=0",
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "browser accept header",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			want:   httpHeaderContentTypeHTML,
+			wantOK: true,
+		},
+		{
+			name:   "case insensitive",
+			accept: "TEXT/Plain;Q=0.5, Application/XML;q=0.4",
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "whitespace around elements and parameters",
+			accept: "  application/xml ; q=0.3 ,  text/plain ;q=0.6  ",
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "UTF-8 charset parameter matches",
+			accept: "text/plain;charset=UTF-8",
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "other charset does not match",
+			accept: "text/plain;charset=iso-8859-1",
+			wantOK: false,
+		},
+		{
+			name:   "quoted parameter with separators",
+			accept: `text/plain;charset="utf-8";q=0.5, text/html;foo="a,b;c";q=0.9, application/xml;q=0.7`,
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "unknown parameter does not match",
+			accept: "application/json;version=2, text/plain;q=0.1",
+			want:   httpHeaderContentTypePlain,
+			wantOK: true,
+		},
+		{
+			name:   "accept extensions after weight are ignored",
+			accept: "application/xml;q=0.5;ext=1, application/json;q=0.4",
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "quality with 3 decimals",
+			accept: "application/json;q=0.001, application/xml;q=0.002",
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "quality with more than 3 decimals is invalid",
+			accept: "application/json;q=0.5, application/xml;q=0.6666",
+			want:   httpHeaderContentTypeJSON,
+			wantOK: true,
+		},
+		{
+			name:   "quality 1.000",
+			accept: "application/xml;q=1.000, application/json;q=0.999",
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "invalid ranges are ignored",
+			accept: "garbage, */json, text, application/xml",
+			want:   httpHeaderContentTypeXML,
+			wantOK: true,
+		},
+		{
+			name:   "only invalid ranges",
+			accept: "garbage, ;;;, /",
+			wantOK: false,
+		},
+		{
+			name:   "unsupported type",
+			accept: "image/png",
+			wantOK: false,
+		},
+		{
+			name:   "empty elements",
+			accept: ",,text/html,,",
+			want:   httpHeaderContentTypeHTML,
+			wantOK: true,
+		},
+		{
+			name:   "custom offers",
+			accept: "application/*",
+			offers: []string{"text/csv", "application/health+json"},
+			want:   "application/health+json",
+			wantOK: true,
+		},
+		{
+			name:   "no offers",
+			accept: "*/*",
+			offers: []string{},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offers := tt.offers
+			if offers == nil {
+				offers = DefaultEncoderRegistry.MediaTypes()
+			}
+			got, gotOK := negotiate(tt.accept, offers)
+			assert.Equal(t, tt.wantOK, gotOK)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseQuality(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   float64
+		wantOK bool
+	}{
+		{"0", 0, true},
+		{"1", 1, true},
+		{"0.", 0, true},
+		{"0.5", 0.5, true},
+		{"0.125", 0.125, true},
+		{"1.0", 1, true},
+		{"1.000", 1, true},
+		{"1.001", 0, false},
+		{"0.1234", 0, false},
+		{"-0.5", 0, false},
+		{"1.5", 0, false},
+		{".5", 0, false},
+		{"0.a", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, gotOK := parseQuality(tt.value)
+			assert.Equal(t, tt.wantOK, gotOK)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWithNotAcceptable(tt *testing.T) {
+	tt.Run("enabled", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPReady(proberesponder.New(), WithNotAcceptable())(w, httpReq("image/png"))
+
+		asserter.Equal(http.StatusNotAcceptable, w.Result().StatusCode)
+		asserter.Equal(strings.Join(DefaultEncoderRegistry.MediaTypes(), ","), w.Header().Get(httpHeaderAccept))
+		asserter.Contains(w.Body.String(), httpHeaderContentTypeJSON)
+	})
+
+	tt.Run("disabled", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		HTTPReady(proberesponder.New())(w, httpReq("image/png"))
+
+		asserter.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+		asserter.Equal(httpHeaderContentTypeJSON, w.Header().Get(httpHeaderContentType))
+	})
+
+	tt.Run("multiple accept headers", func(t *testing.T) {
+		asserter := assert.New(t)
+		w := httptest.NewRecorder()
+		r := httpReq("image/png")
+		r.Header.Add(httpHeaderAccept, "text/plain;q=0.5")
+		HTTPReady(proberesponder.New(), WithNotAcceptable())(w, r)
+
+		asserter.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+		asserter.Equal(httpHeaderContentTypePlain, w.Header().Get(httpHeaderContentType))
+	})
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"sort"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+// Ordering is the order in which the entries of the health response are encoded
+type Ordering int
+
+const (
+	// OrderProbesFirst lists the probe statuses (startup, ready, live) first, followed
+	// by all other keys sorted
+	OrderProbesFirst Ordering = iota
+	// OrderSorted lists all the keys sorted
+	OrderSorted
+	// OrderRegistration lists the keys in the order they were added to the ProbeResponder
+	OrderRegistration
+)
+
+var probeKeys = []string{
+	proberesponder.StatusStartup.PayloadKey(),
+	proberesponder.StatusReady.PayloadKey(),
+	proberesponder.StatusLive.PayloadKey(),
+}
+
+// Entry is a single key value pair of the health response
+type Entry struct {
+	Key   string
+	Value string
+}
+
+// WithOrdering sets the order in which the entries are encoded, OrderProbesFirst by default
+func WithOrdering(ord Ordering) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		hcfg.ordering = ord
+	}
+}
+
+// orderEntries returns the entries of the payload in the given order. registered are
+// the keys in the order of registration, it is only used for OrderRegistration. Keys of
+// the payload which are not in registered, are listed at the end, sorted.
+func orderEntries(ord Ordering, payload map[string]string, registered []string) []Entry {
+	entries := make([]Entry, 0, len(payload))
+	listed := make(map[string]bool, len(payload))
+	appendKeys := func(keys []string) {
+		for _, key := range keys {
+			value, ok := payload[key]
+			if !ok || listed[key] {
+				continue
+			}
+			listed[key] = true
+			entries = append(entries, Entry{Key: key, Value: value})
+		}
+	}
+
+	switch ord {
+	case OrderProbesFirst:
+		appendKeys(probeKeys)
+	case OrderRegistration:
+		appendKeys(registered)
+	}
+
+	remaining := make([]string, 0, len(payload)-len(entries))
+	for key := range payload {
+		if !listed[key] {
+			remaining = append(remaining, key)
+		}
+	}
+	sort.Strings(remaining)
+	appendKeys(remaining)
+
+	return entries
+}
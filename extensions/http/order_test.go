@@ -0,0 +1,122 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_orderEntries(t *testing.T) {
+	payload := map[string]string{
+		"zeta":           "1",
+		"probe->live":    "2",
+		"alpha":          "3",
+		"probe->startup": "4",
+		"probe->ready":   "5",
+	}
+	registered := []string{"probe->live", "zeta", "missing", "probe->ready"}
+
+	keys := func(entries []Entry) []string {
+		list := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			list = append(list, entry.Key)
+		}
+		return list
+	}
+
+	tests := []struct {
+		name string
+		ord  Ordering
+		want []string
+	}{
+		{
+			name: "probes first",
+			ord:  OrderProbesFirst,
+			want: []string{"probe->startup", "probe->ready", "probe->live", "alpha", "zeta"},
+		},
+		{
+			name: "sorted",
+			ord:  OrderSorted,
+			want: []string{"alpha", "probe->live", "probe->ready", "probe->startup", "zeta"},
+		},
+		{
+			name: "registration, with unregistered keys sorted at the end",
+			ord:  OrderRegistration,
+			want: []string{"probe->live", "zeta", "probe->ready", "alpha", "probe->startup"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := orderEntries(tt.ord, payload, registered)
+			assert.Equal(t, tt.want, keys(entries))
+			for _, entry := range entries {
+				assert.Equal(t, payload[entry.Key], entry.Value)
+			}
+		})
+	}
+}
+
+func TestWithOrdering(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.AppendHealthResponse("zeta", "OK")
+	pRes.AppendHealthResponse("alpha", "OK")
+
+	// the position of each key, in every format, is compared to the expected order
+	assertOrder := func(t *testing.T, body string, want []string) {
+		last := -1
+		for _, key := range want {
+			idx := strings.Index(body, key)
+			assert.Greater(t, idx, last, "%s out of order in %s", key, body)
+			last = idx
+		}
+	}
+
+	formats := []string{
+		httpHeaderContentTypeJSON,
+		httpHeaderContentTypeHTML,
+		httpHeaderContentTypePlain,
+		httpHeaderContentTypeXML,
+	}
+
+	tests := []struct {
+		name string
+		opts []HandlerOption
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{"startup", "ready", "live", "alpha", "zeta"},
+		},
+		{
+			name: "sorted",
+			opts: []HandlerOption{WithOrdering(OrderSorted)},
+			want: []string{"alpha", "live", "ready", "startup", "zeta"},
+		},
+		{
+			name: "registration",
+			opts: []HandlerOption{WithOrdering(OrderRegistration)},
+			want: []string{"live", "ready", "startup", "zeta", "alpha"},
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			handler := HTTPReady(pRes, tc.opts...)
+			for _, format := range formats {
+				// repeated to ensure the order does not depend on map iteration
+				for i := 0; i < 5; i++ {
+					w := httptest.NewRecorder()
+					r := httptest.NewRequest(http.MethodGet, HTTPPathReady+"?all", nil)
+					r.Header.Set(httpHeaderAccept, format)
+					handler(w, r)
+					assertOrder(t, w.Body.String(), tc.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const httpHeaderAllow = "Allow"
+
+// method returns the normalized method of the handler
+func (h Handler) method() string {
+	return strings.ToUpper(strings.TrimSpace(h.Method))
+}
+
+// methodRouter dispatches the requests of a single path, by method
+type methodRouter struct {
+	handlers map[string]http.HandlerFunc
+	// allow is the value of the Allow header, i.e. all the methods allowed for the path
+	allow string
+}
+
+func (mr *methodRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, ok := mr.handlers[r.Method]
+	if !ok {
+		w.Header().Set(httpHeaderAllow, mr.allow)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	handler(w, r)
+}
+
+// newServeMux returns a mux with all the handlers. A path can have multiple handlers, each
+// for different methods, and HEAD is allowed wherever GET is. Requests with methods not
+// allowed for a path are responded with 405 Method Not Allowed, and the Allow header. If
+// multiple handlers are registered for the same path & method, the first one is used.
+func newServeMux(handlers []Handler) *http.ServeMux {
+	routers := map[string]*methodRouter{}
+	paths := make([]string, 0, len(handlers))
+
+	for _, h := range handlers {
+		mr, ok := routers[h.Path]
+		if !ok {
+			mr = &methodRouter{handlers: map[string]http.HandlerFunc{}}
+			routers[h.Path] = mr
+			paths = append(paths, h.Path)
+		}
+
+		method := h.method()
+		if _, exists := mr.handlers[method]; !exists && method != "" {
+			mr.handlers[method] = h.Handler
+		}
+	}
+
+	smux := http.NewServeMux()
+	for _, path := range paths {
+		mr := routers[path]
+		_, head := mr.handlers[http.MethodHead]
+		if get, ok := mr.handlers[http.MethodGet]; ok && !head {
+			// the body written by the handler is discarded by net/http for HEAD requests
+			mr.handlers[http.MethodHead] = get
+		}
+
+		allowed := make([]string, 0, len(mr.handlers))
+		for method := range mr.handlers {
+			allowed = append(allowed, method)
+		}
+		sort.Strings(allowed)
+		mr.allow = strings.Join(allowed, ", ")
+		smux.Handle(path, mr)
+	}
+
+	return smux
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func respondWith(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func Test_newServeMux(tt *testing.T) {
+	smux := newServeMux([]Handler{
+		{Method: http.MethodGet, Path: "/multi", Handler: respondWith("get")},
+		{Method: "put", Path: "/multi", Handler: respondWith("put")},
+		{Method: http.MethodPost, Path: "/multi", Handler: respondWith("post")},
+		{Method: http.MethodDelete, Path: "/multi", Handler: respondWith("delete")},
+		{Method: http.MethodGet, Path: "/multi", Handler: respondWith("duplicate")},
+		{Method: http.MethodGet, Path: "/head", Handler: respondWith("get")},
+		{Method: http.MethodHead, Path: "/head", Handler: respondWith("head")},
+		{Method: http.MethodPost, Path: "/post", Handler: respondWith("post")},
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{name: "get", method: http.MethodGet, path: "/multi", wantStatus: http.StatusOK, wantBody: "get"},
+		{name: "method normalized", method: http.MethodPut, path: "/multi", wantStatus: http.StatusOK, wantBody: "put"},
+		{name: "separate handler", method: http.MethodDelete, path: "/multi", wantStatus: http.StatusOK, wantBody: "delete"},
+		{name: "implicit head", method: http.MethodHead, path: "/multi", wantStatus: http.StatusOK, wantBody: "get"},
+		{name: "explicit head", method: http.MethodHead, path: "/head", wantStatus: http.StatusOK, wantBody: "head"},
+		{
+			name:       "not allowed",
+			method:     http.MethodPatch,
+			path:       "/multi",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "DELETE, GET, HEAD, POST, PUT",
+		},
+		{
+			name:       "no head without get",
+			method:     http.MethodHead,
+			path:       "/post",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "POST",
+		},
+		{name: "unknown path", method: http.MethodGet, path: "/unknown", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			w := httptest.NewRecorder()
+			smux.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			asserter.Equal(tc.wantStatus, w.Result().StatusCode)
+			asserter.Equal(tc.wantAllow, w.Header().Get(httpHeaderAllow))
+			if tc.wantBody != "" {
+				asserter.Equal(tc.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_Head(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.SetNotLive(false)
+	srv := httptest.NewServer(Server(pRes, "", 1234).Handler)
+	defer srv.Close()
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: HTTPPathStartup, wantStatus: http.StatusServiceUnavailable},
+		{path: HTTPPathReady, wantStatus: http.StatusServiceUnavailable},
+		{path: HTTPPathLive, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.path, func(t *testing.T) {
+			asserter := assert.New(t)
+			resp, err := srv.Client().Head(srv.URL + tc.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			asserter.NoError(err)
+			asserter.Empty(body)
+			asserter.Equal(tc.wantStatus, resp.StatusCode)
+			asserter.Equal(httpHeaderContentTypeJSON, resp.Header.Get(httpHeaderContentType))
+		})
+	}
+
+	tt.Run("method not allowed", func(t *testing.T) {
+		resp, err := srv.Client().Post(srv.URL+HTTPPathReady, httpHeaderContentTypeJSON, nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "GET, HEAD", resp.Header.Get(httpHeaderAllow))
+	})
+}
@@ -0,0 +1,332 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+const (
+	// Kubernetes style aliases of the probe paths, registered using WithKubernetesAliases
+	HTTPPathHealthz = "/healthz"
+	HTTPPathLivez   = "/livez"
+	HTTPPathReadyz  = "/readyz"
+)
+
+// Timeouts of the server, as in http.Server. A zero or negative value means no timeout.
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+// DefaultTimeouts are the timeouts of the server, unless configured using WithTimeouts
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		ReadHeader: time.Second,
+		Read:       time.Second,
+		Write:      time.Second * 5,
+		Idle:       time.Minute,
+	}
+}
+
+// ServerOption configures the probe server, while initializing with NewServer
+type ServerOption func(scfg *serverConfig)
+
+type serverConfig struct {
+	handlers    []Handler
+	handlerOpts []HandlerOption
+	// admin authorizes the admin handlers, which are registered only if adminEnabled is true
+	admin        Authorizer
+	adminEnabled bool
+
+	prefix          string
+	paths           map[proberesponder.Statuskey]string
+	aliases         bool
+	withoutDefaults bool
+
+	timeouts    Timeouts
+	tlsConfig   *tls.Config
+	certFile    string
+	keyFile     string
+	listener    net.Listener
+	unixSocket  string
+	baseContext context.Context
+}
+
+func newServerConfig(opts ...ServerOption) *serverConfig {
+	scfg := &serverConfig{
+		timeouts: DefaultTimeouts(),
+		paths: map[proberesponder.Statuskey]string{
+			proberesponder.StatusStartup: HTTPPathStartup,
+			proberesponder.StatusReady:   HTTPPathReady,
+			proberesponder.StatusLive:    HTTPPathLive,
+		},
+	}
+	for _, opt := range opts {
+		opt(scfg)
+	}
+	return scfg
+}
+
+// WithHandlers adds the handlers to the server, in addition to the default probe handlers
+func WithHandlers(handlers ...Handler) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.handlers = append(scfg.handlers, handlers...)
+	}
+}
+
+// WithHandlerOptions sets the options of the default probe handlers, e.g. WithAuthorizer
+// to restrict the full details to authorized callers
+func WithHandlerOptions(opts ...HandlerOption) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.handlerOpts = append(scfg.handlerOpts, opts...)
+	}
+}
+
+// WithPathPrefix prepends the prefix to the paths of all the routes, including the ones
+// added using WithHandlers. e.g. with the prefix "/internal", ready is served at
+// "/internal/-/ready".
+func WithPathPrefix(prefix string) ServerOption {
+	return func(scfg *serverConfig) {
+		prefix = strings.Trim(prefix, "/")
+		if prefix != "" {
+			prefix = "/" + prefix
+		}
+		scfg.prefix = prefix
+	}
+}
+
+// WithProbePath sets the path of the default handler of the status, instead of the
+// respective HTTPPath* constant. A leading "/" is added if missing, e.g. "ready" is served at
+// "/ready".
+func WithProbePath(status proberesponder.Statuskey, path string) ServerOption {
+	return func(scfg *serverConfig) {
+		if _, ok := scfg.paths[status]; ok && path != "" {
+			scfg.paths[status] = "/" + strings.TrimPrefix(path, "/")
+		}
+	}
+}
+
+// WithKubernetesAliases additionally serves live at /livez & /healthz, and ready at /readyz
+func WithKubernetesAliases() ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.aliases = true
+	}
+}
+
+// WithoutDefaultHandlers does not register the default probe handlers, only the handlers
+// added using WithHandlers (and the admin handlers, if enabled) are served
+func WithoutDefaultHandlers() ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.withoutDefaults = true
+	}
+}
+
+// WithTimeouts sets the timeouts of the server, instead of DefaultTimeouts
+func WithTimeouts(timeouts Timeouts) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.timeouts = timeouts
+	}
+}
+
+// WithListener serves the probes using the listener, instead of listening on the host & port
+func WithListener(listener net.Listener) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.listener = listener
+	}
+}
+
+// WithUnixSocket serves the probes on the Unix domain socket at path, instead of listening
+// on the host & port. A stale socket file at the path, left behind by a previous run, is
+// removed before listening. If another process is listening on the socket, Listen returns an
+// error wrapping syscall.EADDRINUSE instead.
+func WithUnixSocket(path string) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.unixSocket = path
+	}
+}
+
+// WithBaseContext sets the base context of all the requests, e.g. to cancel long running
+// requests like HTTPEvents when the app is shutting down
+func WithBaseContext(ctx context.Context) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.baseContext = ctx
+	}
+}
+
+// routes returns all the routes of the server, with the prefix
+func (scfg *serverConfig) routes(pres *proberesponder.ProbeResponder) []Handler {
+	handlers := append([]Handler(nil), scfg.handlers...)
+	if !scfg.withoutDefaults {
+		startup := HTTPStartup(pres, scfg.handlerOpts...)
+		ready := HTTPReady(pres, scfg.handlerOpts...)
+		live := HTTPLive(pres, scfg.handlerOpts...)
+
+		handlers = append(handlers, []Handler{
+			{Method: http.MethodGet, Path: scfg.paths[proberesponder.StatusStartup], Handler: startup},
+			{Method: http.MethodGet, Path: scfg.paths[proberesponder.StatusReady], Handler: ready},
+			{Method: http.MethodGet, Path: scfg.paths[proberesponder.StatusLive], Handler: live},
+		}...)
+
+		if scfg.aliases {
+			handlers = append(handlers, []Handler{
+				{Method: http.MethodGet, Path: HTTPPathHealthz, Handler: live},
+				{Method: http.MethodGet, Path: HTTPPathLivez, Handler: live},
+				{Method: http.MethodGet, Path: HTTPPathReadyz, Handler: ready},
+			}...)
+		}
+	}
+
+	if scfg.adminEnabled {
+		handlers = append(handlers, AdminHandlers(pres, scfg.admin, scfg.handlerOpts...)...)
+	}
+
+	for i := range handlers {
+		handlers[i].Path = scfg.prefix + handlers[i].Path
+	}
+
+	return handlers
+}
+
+// Routes returns all the routes as configured by the options, e.g. to be registered with
+// an existing router
+func Routes(pres *proberesponder.ProbeResponder, opts ...ServerOption) []Handler {
+	return newServerConfig(opts...).routes(pres)
+}
+
+// NewServeMux returns a mux serving all the routes as configured by the options, e.g. to
+// be mounted into an existing mux
+func NewServeMux(pres *proberesponder.ProbeResponder, opts ...ServerOption) *http.ServeMux {
+	return newServeMux(Routes(pres, opts...))
+}
+
+// ProbeServer is the HTTP server responding to the probes
+type ProbeServer struct {
+	*http.Server
+	scfg *serverConfig
+	// err is the error while configuring the server, returned when starting it
+	err error
+}
+
+// NewServer returns a basic/standard Golang HTTP server with the 3 default handlers for
+// probes, configured with the options. Errors in the configuration, e.g. invalid TLS
+// certificate files, are returned when the server is started.
+func NewServer(pres *proberesponder.ProbeResponder, host string, port uint16, opts ...ServerOption) *ProbeServer {
+	scfg := newServerConfig(opts...)
+	tlsConfig, err := scfg.tls()
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", host, port),
+		Handler:           newServeMux(scfg.routes(pres)),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: scfg.timeouts.ReadHeader,
+		ReadTimeout:       scfg.timeouts.Read,
+		WriteTimeout:      scfg.timeouts.Write,
+		IdleTimeout:       scfg.timeouts.Idle,
+	}
+	if scfg.baseContext != nil {
+		srv.BaseContext = func(net.Listener) context.Context {
+			return scfg.baseContext
+		}
+	}
+
+	return &ProbeServer{
+		Server: srv,
+		scfg:   scfg,
+		err:    err,
+	}
+}
+
+// Listen returns the listener as configured by the options, i.e. the listener set using
+// WithListener, or a Unix domain socket set using WithUnixSocket, or else a TCP listener on
+// the address of the server. If TLS is configured, the listener is wrapped with it.
+func (ps *ProbeServer) Listen() (net.Listener, error) {
+	if ps.err != nil {
+		return nil, ps.err
+	}
+
+	listener := ps.scfg.listener
+	if listener == nil {
+		var err error
+		listener, err = ps.listen()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ps.TLSConfig != nil {
+		listener = tls.NewListener(listener, ps.TLSConfig)
+	}
+
+	return listener, nil
+}
+
+func (ps *ProbeServer) listen() (net.Listener, error) {
+	if ps.scfg.unixSocket == "" {
+		return net.Listen("tcp", ps.Addr)
+	}
+
+	info, err := os.Stat(ps.scfg.unixSocket)
+	switch {
+	case err == nil && info.Mode().Type() == fs.ModeSocket:
+		err = removeStaleSocket(ps.scfg.unixSocket)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	return net.Listen("unix", ps.scfg.unixSocket)
+}
+
+// removeStaleSocket removes the socket, only if nothing is listening on it. e.g. left behind
+// by a previous run which did not exit cleanly.
+func removeStaleSocket(path string) error {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("socket %s: %w", path, syscall.EADDRINUSE)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("socket %s: %w", path, err)
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		return fmt.Errorf("failed removing stale socket: %w", err)
+	}
+	return nil
+}
+
+// ListenAndServe listens as configured by the options (see Listen), and serves the probes.
+// It always returns a non-nil error, http.ErrServerClosed after Shutdown or Close.
+func (ps *ProbeServer) ListenAndServe() error {
+	listener, err := ps.Listen()
+	if err != nil {
+		return err
+	}
+	return ps.Server.Serve(listener)
+}
+
+// Server is a basic/standard Golang HTTP server with the 3 default handlers for probes
+func Server(pres *proberesponder.ProbeResponder, host string, port uint16, handlers ...Handler) *http.Server {
+	return NewServer(pres, host, port, WithHandlers(handlers...)).Server
+}
+
+// StartHTTPServer directly initializes and starts a basic HTTP probe responder
+func StartHTTPServer(pres *proberesponder.ProbeResponder, host string, port uint16) error {
+	return Server(pres, host, port).ListenAndServe()
+}
@@ -0,0 +1,250 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.SetNotLive(false)
+
+	paths := func(handlers []Handler) []string {
+		list := make([]string, 0, len(handlers))
+		for _, h := range handlers {
+			list = append(list, h.Path)
+		}
+		return list
+	}
+
+	tests := []struct {
+		name string
+		opts []ServerOption
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{HTTPPathStartup, HTTPPathReady, HTTPPathLive},
+		},
+		{
+			name: "prefix",
+			opts: []ServerOption{
+				WithPathPrefix("internal/"),
+				WithHandlers(Handler{Method: http.MethodGet, Path: HTTPPathInfo, Handler: HTTPInfo()}),
+			},
+			want: []string{"/internal/-/info", "/internal/-/startup", "/internal/-/ready", "/internal/-/live"},
+		},
+		{
+			name: "root prefix",
+			opts: []ServerOption{WithPathPrefix("/")},
+			want: []string{HTTPPathStartup, HTTPPathReady, HTTPPathLive},
+		},
+		{
+			name: "custom paths",
+			opts: []ServerOption{
+				WithProbePath(proberesponder.StatusReady, "/ready"),
+				WithProbePath(proberesponder.StatusLive, ""),
+				WithProbePath(proberesponder.Statuskey("unknown"), "/unknown"),
+			},
+			want: []string{HTTPPathStartup, "/ready", HTTPPathLive},
+		},
+		{
+			name: "custom paths without leading slash",
+			opts: []ServerOption{
+				WithProbePath(proberesponder.StatusReady, "ready"),
+				WithPathPrefix("internal"),
+			},
+			want: []string{"/internal/-/startup", "/internal/ready", "/internal/-/live"},
+		},
+		{
+			name: "kubernetes aliases",
+			opts: []ServerOption{WithKubernetesAliases(), WithPathPrefix("/probes")},
+			want: []string{
+				"/probes/-/startup", "/probes/-/ready", "/probes/-/live",
+				"/probes/healthz", "/probes/livez", "/probes/readyz",
+			},
+		},
+		{
+			name: "without defaults",
+			opts: []ServerOption{
+				WithoutDefaultHandlers(),
+				WithKubernetesAliases(),
+				WithHandlers(Handler{Method: http.MethodGet, Path: HTTPPathInfo, Handler: HTTPInfo()}),
+			},
+			want: []string{HTTPPathInfo},
+		},
+		{
+			name: "without defaults, with admin",
+			opts: []ServerOption{WithoutDefaultHandlers(), WithAdmin(nil)},
+			want: []string{HTTPPathAdminStartup, HTTPPathAdminReady, HTTPPathAdminLive},
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, paths(Routes(pRes, tc.opts...)))
+		})
+	}
+}
+
+func TestNewServeMux(tt *testing.T) {
+	pRes := proberesponder.New()
+	pRes.SetNotLive(false)
+	smux := NewServeMux(pRes, WithPathPrefix("/internal"), WithKubernetesAliases())
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{path: "/internal/livez", wantStatus: http.StatusOK},
+		{path: "/internal/healthz", wantStatus: http.StatusOK},
+		{path: "/internal/readyz", wantStatus: http.StatusServiceUnavailable},
+		{path: "/internal/-/live", wantStatus: http.StatusOK},
+		{path: HTTPPathLive, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			smux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.wantStatus, w.Result().StatusCode)
+		})
+	}
+
+	tt.Run("custom path without leading slash", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewServeMux(pRes, WithProbePath(proberesponder.StatusLive, "live")).
+			ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/live", nil))
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	tt.Run("mounted into an existing mux", func(t *testing.T) {
+		app := http.NewServeMux()
+		app.Handle("/internal/", smux)
+		app.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("app"))
+		})
+
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal/livez", nil))
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		w = httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+		assert.Equal(t, "app", w.Body.String())
+	})
+}
+
+func TestNewServer_Options(tt *testing.T) {
+	tt.Run("default timeouts", func(t *testing.T) {
+		asserter := assert.New(t)
+		srv := NewServer(proberesponder.New(), "localhost", 2000)
+		asserter.Equal("localhost:2000", srv.Addr)
+		asserter.Equal(time.Second, srv.ReadHeaderTimeout)
+		asserter.Equal(time.Second, srv.ReadTimeout)
+		asserter.Equal(5*time.Second, srv.WriteTimeout)
+		asserter.Equal(time.Minute, srv.IdleTimeout)
+		asserter.Nil(srv.TLSConfig)
+		asserter.Nil(srv.BaseContext)
+	})
+
+	tt.Run("timeouts", func(t *testing.T) {
+		asserter := assert.New(t)
+		srv := NewServer(proberesponder.New(), "", 2000, WithTimeouts(Timeouts{
+			ReadHeader: time.Millisecond,
+			Read:       2 * time.Millisecond,
+			Write:      3 * time.Millisecond,
+		}))
+		asserter.Equal(time.Millisecond, srv.ReadHeaderTimeout)
+		asserter.Equal(2*time.Millisecond, srv.ReadTimeout)
+		asserter.Equal(3*time.Millisecond, srv.WriteTimeout)
+		asserter.Zero(srv.IdleTimeout)
+	})
+
+	tt.Run("base context", func(t *testing.T) {
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "base")
+		srv := NewServer(proberesponder.New(), "", 2000, WithBaseContext(ctx))
+		assert.Equal(t, "base", srv.BaseContext(nil).Value(ctxKey{}))
+	})
+
+	tt.Run("listener", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		pRes := proberesponder.New()
+		pRes.SetNotLive(false)
+		srv := NewServer(pRes, "", 0, WithListener(listener))
+		go func() { _ = srv.ListenAndServe() }()
+		defer srv.Close()
+
+		resp, err := http.Get("http://" + listener.Addr().String() + HTTPPathLive)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	tt.Run("unix socket", func(t *testing.T) {
+		asserter := assert.New(t)
+		socket := filepath.Join(t.TempDir(), "probes.sock")
+
+		// a stale socket of a previous run
+		stale, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		pRes := proberesponder.New()
+		pRes.SetNotLive(false)
+		srv := NewServer(pRes, "", 0, WithUnixSocket(socket))
+		listener, err := srv.Listen()
+		require.NoError(t, err)
+		go func() { _ = srv.Serve(listener) }()
+		defer srv.Close()
+
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+			},
+		}}
+		resp, err := client.Get("http://probes" + HTTPPathLive)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		asserter.Equal(http.StatusOK, resp.StatusCode)
+	})
+
+	tt.Run("unix socket in use", func(t *testing.T) {
+		asserter := assert.New(t)
+		socket := filepath.Join(t.TempDir(), "probes.sock")
+
+		live, err := net.Listen("unix", socket)
+		require.NoError(t, err)
+		defer live.Close()
+
+		_, err = NewServer(proberesponder.New(), "", 0, WithUnixSocket(socket)).Listen()
+		asserter.ErrorIs(err, syscall.EADDRINUSE)
+
+		// the socket of the live listener is retained
+		conn, err := net.Dial("unix", socket)
+		require.NoError(t, err)
+		asserter.NoError(conn.Close())
+	})
+
+	tt.Run("unix socket path is not a socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "probes.sock")
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+		_, err := NewServer(proberesponder.New(), "", 0, WithUnixSocket(path)).Listen()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,256 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+)
+
+const (
+	// KeyShutdown is the key in the health response with the current phase of the shutdown
+	KeyShutdown = "shutdown"
+	// KeyShutdownHookPrefix is the prefix of the keys in the health response with the result
+	// of each shutdown hook, e.g. "shutdown->app-server"
+	KeyShutdownHookPrefix = "shutdown->"
+
+	// DefaultDeregistrationDelay is the time waited after marking the app NOT ready, for the
+	// load balancers to stop sending new requests
+	DefaultDeregistrationDelay = 15 * time.Second
+	// DefaultShutdownTimeout is the timeout of each shutdown hook, and of the probe server
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// ShutdownPhase is a phase of the graceful shutdown, as reported in the health response
+type ShutdownPhase string
+
+func (sp ShutdownPhase) String() string {
+	return string(sp)
+}
+
+const (
+	ShutdownPhaseDeregistering ShutdownPhase = "deregistering"
+	ShutdownPhaseHooks         ShutdownPhase = "running hooks"
+	ShutdownPhaseProbeServer   ShutdownPhase = "stopping probe server"
+	ShutdownPhaseDone          ShutdownPhase = "done"
+)
+
+// ShutdownHook is a step of the graceful shutdown, e.g. draining in-flight requests, or
+// shutting down the app server
+type ShutdownHook struct {
+	Name string
+	// Timeout of the hook, DefaultShutdownTimeout if not set. The context passed to Fn is
+	// cancelled after the timeout.
+	Timeout time.Duration
+	Fn      func(ctx context.Context) error
+}
+
+// ShutdownOption configures the Shutdown coordinator
+type ShutdownOption func(sd *Shutdown)
+
+// Shutdown coordinates the graceful shutdown of the app. i.e. on receiving any of the
+// signals (SIGTERM & interrupt by default), it
+//
+//  1. holds ready as NOT OK (see ProbeResponder.Hold)
+//  2. waits for the deregistration delay, for load balancers to stop sending new requests
+//  3. runs the hooks in order, each with its own timeout
+//  4. shuts down the probe server
+//  5. flushes the persisted state, if persistence is enabled
+//
+// Each phase is reported in the health response with the key KeyShutdown, and the result
+// of each hook with the key KeyShutdownHookPrefix + hook name.
+type Shutdown struct {
+	pres                *proberesponder.ProbeResponder
+	deregistrationDelay time.Duration
+	hooks               []ShutdownHook
+	signals             []os.Signal
+	probeServer         *ProbeServer
+	probeServerTimeout  time.Duration
+	logger              loggerOption
+
+	once *sync.Once
+	err  error
+}
+
+// WithDeregistrationDelay sets the time waited after setting ready as NOT OK, before running
+// the hooks, instead of DefaultDeregistrationDelay
+func WithDeregistrationDelay(delay time.Duration) ShutdownOption {
+	return func(sd *Shutdown) {
+		sd.deregistrationDelay = delay
+	}
+}
+
+// WithShutdownHook adds a hook to be run during the shutdown, hooks are run in the order
+// they're added. e.g. WithShutdownHook("app-server", 30*time.Second, appServer.Shutdown)
+func WithShutdownHook(name string, timeout time.Duration, fn func(ctx context.Context) error) ShutdownOption {
+	return func(sd *Shutdown) {
+		if fn == nil {
+			return
+		}
+		sd.hooks = append(sd.hooks, ShutdownHook{Name: name, Timeout: timeout, Fn: fn})
+	}
+}
+
+// WithShutdownSignals sets the signals which trigger the shutdown, instead of SIGTERM &
+// interrupt
+func WithShutdownSignals(signals ...os.Signal) ShutdownOption {
+	return func(sd *Shutdown) {
+		sd.signals = signals
+	}
+}
+
+// WithProbeServer sets the probe server, which is shut down last, with the timeout
+func WithProbeServer(srv *ProbeServer, timeout time.Duration) ShutdownOption {
+	return func(sd *Shutdown) {
+		sd.probeServer = srv
+		sd.probeServerTimeout = timeout
+	}
+}
+
+// WithShutdownLogger sets the logger of the shutdown, instead of the one set using SetLogger.
+// A nil logger disables logging.
+func WithShutdownLogger(lg *logging.Logger) ShutdownOption {
+	return func(sd *Shutdown) {
+		sd.logger = loggerOption{logger: lg, set: true}
+	}
+}
+
+// NewShutdown returns the shutdown coordinator, the shutdown is triggered by Wait on
+// receiving a signal, or by calling Shutdown directly
+func NewShutdown(pres *proberesponder.ProbeResponder, opts ...ShutdownOption) *Shutdown {
+	sd := &Shutdown{
+		pres:                pres,
+		deregistrationDelay: DefaultDeregistrationDelay,
+		signals:             []os.Signal{syscall.SIGTERM, os.Interrupt},
+		probeServerTimeout:  DefaultShutdownTimeout,
+		once:                &sync.Once{},
+	}
+	for _, opt := range opts {
+		opt(sd)
+	}
+	return sd
+}
+
+// Wait blocks till any of the signals is received, and then shuts down. It returns
+// ctx.Err() if ctx is done before receiving any signal, without shutting down.
+func (sd *Shutdown) Wait(ctx context.Context) error {
+	sigCtx, stop := signal.NotifyContext(ctx, sd.signals...)
+	defer stop()
+
+	<-sigCtx.Done()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return sd.Shutdown(context.Background())
+}
+
+// Shutdown runs all the phases of the shutdown, it is run only once and subsequent calls
+// return the result of the first. Failure of a hook does not stop the shutdown, all the
+// errors are returned combined. If ctx is done, the remaining phases are cut short.
+func (sd *Shutdown) Shutdown(ctx context.Context) error {
+	sd.once.Do(func() {
+		sd.err = sd.shutdown(ctx)
+	})
+	return sd.err
+}
+
+func (sd *Shutdown) shutdown(ctx context.Context) error {
+	errs := make([]error, 0, len(sd.hooks)+2)
+
+	sd.phase(ctx, ShutdownPhaseDeregistering)
+	// ready is held as NOT OK, so that it's not set as OK again by the dependency probes. It
+	// is never released, since the app is exiting.
+	_ = sd.pres.Hold(proberesponder.StatusReady, "shutting down")
+	select {
+	case <-ctx.Done():
+	case <-time.After(sd.deregistrationDelay):
+	}
+
+	sd.phase(ctx, ShutdownPhaseHooks)
+	for _, hook := range sd.hooks {
+		err := sd.runHook(ctx, hook)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if sd.probeServer != nil {
+		sd.phase(ctx, ShutdownPhaseProbeServer)
+		err := sd.runHook(ctx, ShutdownHook{
+			Name:    "probe-server",
+			Timeout: sd.probeServerTimeout,
+			Fn:      sd.probeServer.Shutdown,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	sd.phase(ctx, ShutdownPhaseDone)
+
+	// the state is persisted before exiting, including the phases of the shutdown
+	err := sd.pres.Flush()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed persisting state: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (sd *Shutdown) phase(ctx context.Context, phase ShutdownPhase) {
+	sd.pres.AppendHealthResponse(KeyShutdown, fmt.Sprintf("%s: %s", phase, time.Now().Format(time.RFC3339)))
+	sd.logger.log().Log(ctx, logging.EventShutdownPhase, "shutdown phase", slog.String("phase", phase.String()))
+}
+
+func (sd *Shutdown) runHook(ctx context.Context, hook ShutdownHook) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	hctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	key := KeyShutdownHookPrefix + hook.Name
+	sd.pres.AppendHealthResponse(key, "started: "+start.Format(time.RFC3339))
+
+	// the hook is run in a separate goroutine, so that a hook ignoring the context does
+	// not block the shutdown beyond its timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- hook.Fn(hctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-hctx.Done():
+		err = hctx.Err()
+	}
+
+	if err == nil {
+		sd.pres.AppendHealthResponse(key, fmt.Sprintf("%s: %s", proberesponder.HealthOK, time.Since(start)))
+		return nil
+	}
+
+	err = fmt.Errorf("shutdown hook %s failed: %w", hook.Name, err)
+	sd.pres.AppendHealthResponse(key, fmt.Sprintf("%s: %s", proberesponder.HealthNotOK, err))
+	sd.logger.log().Log(
+		ctx,
+		logging.EventShutdownFailure,
+		"shutdown hook failed",
+		slog.String("hook", hook.Name),
+		slog.String("error", err.Error()),
+	)
+
+	return err
+}
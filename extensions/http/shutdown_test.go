@@ -0,0 +1,170 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/depprober"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdown(tt *testing.T) {
+	tt.Run("phases", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+
+		locker := sync.Mutex{}
+		steps := []string{}
+		record := func(step string) {
+			locker.Lock()
+			defer locker.Unlock()
+			steps = append(steps, step)
+		}
+
+		// phases observed as reported in the health response
+		unsubscribe := pRes.Subscribe(func(changes []proberesponder.Change) {
+			for _, ch := range changes {
+				if ch.Key == KeyShutdown {
+					record(strings.SplitN(ch.To, ":", 2)[0])
+				}
+			}
+		})
+		defer unsubscribe()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		probeServer := NewServer(pRes, "", 0, WithListener(listener), WithHandlerOptions(WithLogger(nil)))
+		served := make(chan error, 1)
+		go func() { served <- probeServer.ListenAndServe() }()
+
+		sd := NewShutdown(
+			pRes,
+			WithDeregistrationDelay(20*time.Millisecond),
+			WithShutdownLogger(nil),
+			WithShutdownHook("drain", time.Second, func(ctx context.Context) error {
+				asserter.True(pRes.Statuses()[proberesponder.StatusReady].NotOK)
+				record("drain")
+				return nil
+			}),
+			WithShutdownHook("failing", time.Second, func(ctx context.Context) error {
+				record("failing")
+				return errors.New("boom")
+			}),
+			WithShutdownHook("slow", 10*time.Millisecond, func(ctx context.Context) error {
+				record("slow")
+				// ignores the context
+				time.Sleep(time.Second)
+				return nil
+			}),
+			WithShutdownHook("app-server", time.Second, func(ctx context.Context) error {
+				record("app-server")
+				return nil
+			}),
+			WithShutdownHook("nil", time.Second, nil),
+			WithProbeServer(probeServer, time.Second),
+		)
+
+		start := time.Now()
+		err = sd.Shutdown(context.Background())
+		asserter.Less(time.Since(start), time.Second)
+		asserter.ErrorContains(err, "shutdown hook failing failed: boom")
+		asserter.ErrorIs(err, context.DeadlineExceeded)
+		asserter.Equal(err, sd.Shutdown(context.Background()))
+		asserter.ErrorIs(<-served, http.ErrServerClosed)
+
+		locker.Lock()
+		asserter.Equal([]string{
+			ShutdownPhaseDeregistering.String(),
+			ShutdownPhaseHooks.String(),
+			"drain",
+			"failing",
+			"slow",
+			"app-server",
+			ShutdownPhaseProbeServer.String(),
+			ShutdownPhaseDone.String(),
+		}, steps)
+		locker.Unlock()
+
+		payload := pRes.HealthResponse()
+		asserter.True(strings.HasPrefix(payload[KeyShutdown], ShutdownPhaseDone.String()))
+		asserter.True(strings.HasPrefix(payload[KeyShutdownHookPrefix+"drain"], "OK: "))
+		asserter.True(strings.HasPrefix(payload[KeyShutdownHookPrefix+"failing"], "NOT OK: "))
+		asserter.True(strings.HasPrefix(payload[KeyShutdownHookPrefix+"slow"], "NOT OK: "))
+		asserter.True(strings.HasPrefix(payload[KeyShutdownHookPrefix+"probe-server"], "OK: "))
+		asserter.NotContains(payload, KeyShutdownHookPrefix+"nil")
+		asserter.Equal("shutting down", pRes.Reason(proberesponder.StatusReady))
+	})
+
+	tt.Run("ready is not reset by dependency probes", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		stopper := depprober.Start(10*time.Millisecond, pRes, &depprober.Probe{
+			ID:               "mydb",
+			AffectedStatuses: []proberesponder.Statuskey{proberesponder.StatusReady},
+		})
+		defer stopper.Stop()
+		asserter.Eventually(func() bool {
+			return !pRes.Statuses()[proberesponder.StatusReady].NotOK
+		}, time.Second, 5*time.Millisecond)
+
+		done := make(chan error, 1)
+		sd := NewShutdown(pRes, WithDeregistrationDelay(100*time.Millisecond), WithShutdownLogger(nil))
+		go func() { done <- sd.Shutdown(context.Background()) }()
+
+		asserter.Eventually(func() bool {
+			return pRes.Statuses()[proberesponder.StatusReady].NotOK
+		}, time.Second, time.Millisecond)
+		for {
+			select {
+			case err := <-done:
+				asserter.NoError(err)
+				ready := pRes.Statuses()[proberesponder.StatusReady]
+				asserter.True(ready.NotOK)
+				asserter.Equal("shutting down", ready.Reason)
+				return
+			default:
+				ready := pRes.Statuses()[proberesponder.StatusReady]
+				asserter.True(ready.NotOK)
+				asserter.Equal("shutting down", ready.Reason)
+				time.Sleep(5 * time.Millisecond)
+			}
+		}
+	})
+
+	tt.Run("persisted state is flushed", func(t *testing.T) {
+		asserter := assert.New(t)
+		fpath := filepath.Join(t.TempDir(), "state.json")
+		pRes := proberesponder.New(
+			proberesponder.WithPersistence(fpath),
+			proberesponder.WithPersistenceDelay(time.Hour),
+		)
+
+		sd := NewShutdown(pRes, WithDeregistrationDelay(0), WithShutdownLogger(nil))
+		asserter.NoError(sd.Shutdown(context.Background()))
+
+		raw, err := os.ReadFile(fpath)
+		asserter.NoError(err)
+		asserter.Contains(string(raw), ShutdownPhaseDone.String())
+	})
+
+	tt.Run("cancelled context cuts the delay short", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		start := time.Now()
+		sd := NewShutdown(proberesponder.New(), WithDeregistrationDelay(time.Hour), WithShutdownLogger(nil))
+		assert.NoError(t, sd.Shutdown(ctx))
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
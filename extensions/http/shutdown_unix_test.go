@@ -0,0 +1,53 @@
+//go:build unix
+
+package http
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown_Wait(tt *testing.T) {
+	SetLogger(nil)
+	defer SetLogger(logging.New(nil, nil))
+
+	tt.Run("signal", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+
+		sd := NewShutdown(pRes, WithDeregistrationDelay(0), WithShutdownSignals(syscall.SIGUSR1))
+		waited := make(chan error, 1)
+		go func() { waited <- sd.Wait(context.Background()) }()
+
+		// the signal is sent till it's received, since Wait may not have subscribed yet
+		assert.Eventually(t, func() bool {
+			_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+			return pRes.Statuses()[proberesponder.StatusReady].NotOK
+		}, time.Second, 10*time.Millisecond)
+
+		select {
+		case err := <-waited:
+			asserter.NoError(err)
+		case <-time.After(time.Second):
+			t.Fatal("shutdown not completed")
+		}
+	})
+
+	tt.Run("context done", func(t *testing.T) {
+		pRes := proberesponder.New()
+		pRes.SetNotReady(false)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		sd := NewShutdown(pRes, WithShutdownSignals(syscall.SIGUSR1))
+		assert.ErrorIs(t, sd.Wait(ctx), context.Canceled)
+		assert.False(t, pRes.Statuses()[proberesponder.StatusReady].NotOK)
+	})
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+// ProbeState is the state of a probe, as responded by the probe handlers
+type ProbeState int
+
+const (
+	// ProbeStateOK is when the probe status is OK, and none of the checks are failing
+	ProbeStateOK ProbeState = iota
+	// ProbeStateDegraded is when the probe status is OK, but some of the checks are failing.
+	// e.g. checks which do not affect the status, or are excluded.
+	ProbeStateDegraded
+	// ProbeStateNotOK is when the probe status is NOT OK
+	ProbeStateNotOK
+)
+
+func (ps ProbeState) String() string {
+	switch ps {
+	case ProbeStateOK:
+		return "ok"
+	case ProbeStateDegraded:
+		return "degraded"
+	case ProbeStateNotOK:
+		return "not ok"
+	}
+	return "unknown"
+}
+
+// defaultStatusCodes are the HTTP status codes responded for each of the states
+var defaultStatusCodes = map[ProbeState]int{
+	ProbeStateOK:       http.StatusOK,
+	ProbeStateDegraded: http.StatusOK,
+	ProbeStateNotOK:    http.StatusServiceUnavailable,
+}
+
+// WithStatusCode sets the HTTP status code responded by the probe handlers for the state,
+// instead of 200 for OK & degraded, and 503 for NOT OK. e.g. some load balancers treat 503 as
+// retriable, and need 500 or 429 instead. Codes outside 200-599, and those which do not allow
+// a body (204 & 304), are ignored.
+func WithStatusCode(state ProbeState, code int) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		if !validStatusCode(code) {
+			return
+		}
+		if hcfg.statusCodes == nil {
+			hcfg.statusCodes = make(map[ProbeState]int, len(defaultStatusCodes))
+		}
+		hcfg.statusCodes[state] = code
+	}
+}
+
+// validStatusCode returns true if the code is a complete response with a body
+func validStatusCode(code int) bool {
+	switch code {
+	case http.StatusNoContent, http.StatusNotModified:
+		return false
+	}
+	return code >= http.StatusOK && code <= 599
+}
+
+// WithMinimalBody responds with only the body in plain text for the state, instead of the
+// encoded health response. e.g. "ok" for high frequency probes. The query parameter
+// "verbose" still responds with the result of each check.
+func WithMinimalBody(state ProbeState, body string) HandlerOption {
+	return func(hcfg *handlerConfig) {
+		if hcfg.minimalBodies == nil {
+			hcfg.minimalBodies = make(map[ProbeState]string, len(defaultStatusCodes))
+		}
+		hcfg.minimalBodies[state] = body
+	}
+}
+
+// statusCode returns the HTTP status code of the state
+func (hcfg *handlerConfig) statusCode(state ProbeState) int {
+	if code, ok := hcfg.statusCodes[state]; ok {
+		return code
+	}
+	return defaultStatusCodes[state]
+}
+
+// probeState returns the state of the probe, where the checks excluded are failing but do
+// not affect the status
+func probeState(notOK bool, checks []proberesponder.CheckResult) ProbeState {
+	if notOK {
+		return ProbeStateNotOK
+	}
+
+	for _, cr := range checks {
+		if cr.NotOK {
+			return ProbeStateDegraded
+		}
+	}
+
+	return ProbeStateOK
+}
+
+// respondMinimal responds with the minimal body of the state in plain text, and returns false
+// if there's none configured
+func (hcfg *handlerConfig) respondMinimal(w http.ResponseWriter, r *http.Request, state ProbeState) bool {
+	body, ok := hcfg.minimalBodies[state]
+	if !ok {
+		return false
+	}
+
+	w.Header().Add(httpHeaderContentType, httpHeaderContentTypePlain)
+	w.WriteHeader(hcfg.statusCode(state))
+	hcfg.write(w, r, []byte(body))
+	return true
+}
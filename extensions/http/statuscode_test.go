@@ -0,0 +1,157 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPReady_StatusCodes(tt *testing.T) {
+	minimal := []HandlerOption{
+		WithMinimalBody(ProbeStateOK, "ok"),
+		WithMinimalBody(ProbeStateDegraded, "degraded"),
+	}
+
+	tests := []struct {
+		name       string
+		checks     []proberesponder.CheckResult
+		opts       []HandlerOption
+		query      string
+		wantStatus int
+		wantBody   string
+		wantJSON   bool
+	}{
+		{
+			name:       "ok, default",
+			checks:     []proberesponder.CheckResult{checkCacheOK},
+			wantStatus: http.StatusOK,
+			wantJSON:   true,
+		},
+		{
+			name:       "degraded, default",
+			checks:     []proberesponder.CheckResult{checkCacheOK, checkLiveDn},
+			wantStatus: http.StatusOK,
+			wantJSON:   true,
+		},
+		{
+			name:       "not ok, default",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			wantStatus: http.StatusServiceUnavailable,
+			wantJSON:   true,
+		},
+		{
+			name:       "degraded, custom",
+			checks:     []proberesponder.CheckResult{checkCacheOK, checkLiveDn},
+			opts:       []HandlerOption{WithStatusCode(ProbeStateDegraded, http.StatusMultiStatus)},
+			wantStatus: http.StatusMultiStatus,
+			wantJSON:   true,
+		},
+		{
+			name:       "excluded failing check is degraded",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			opts:       []HandlerOption{WithStatusCode(ProbeStateDegraded, http.StatusMultiStatus)},
+			query:      "?exclude=mydb",
+			wantStatus: http.StatusMultiStatus,
+			wantJSON:   true,
+		},
+		{
+			name:       "not ok, custom",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			opts:       []HandlerOption{WithStatusCode(ProbeStateNotOK, http.StatusTooManyRequests)},
+			wantStatus: http.StatusTooManyRequests,
+			wantJSON:   true,
+		},
+		{
+			name:   "invalid codes are ignored",
+			checks: []proberesponder.CheckResult{checkDBDown},
+			opts: []HandlerOption{
+				WithStatusCode(ProbeStateNotOK, 0),
+				WithStatusCode(ProbeStateNotOK, http.StatusContinue),
+				WithStatusCode(ProbeStateNotOK, http.StatusNoContent),
+				WithStatusCode(ProbeStateNotOK, http.StatusNotModified),
+				WithStatusCode(ProbeStateNotOK, 600),
+				WithStatusCode(ProbeStateNotOK, 1000),
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantJSON:   true,
+		},
+		{
+			name:       "invalid code does not override a valid one",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			opts:       []HandlerOption{WithStatusCode(ProbeStateNotOK, http.StatusTooManyRequests), WithStatusCode(ProbeStateNotOK, 0)},
+			wantStatus: http.StatusTooManyRequests,
+			wantJSON:   true,
+		},
+		{
+			name:       "ok, minimal body",
+			checks:     []proberesponder.CheckResult{checkCacheOK},
+			opts:       minimal,
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:       "degraded, minimal body",
+			checks:     []proberesponder.CheckResult{checkLiveDn},
+			opts:       minimal,
+			wantStatus: http.StatusOK,
+			wantBody:   "degraded",
+		},
+		{
+			name:       "not ok, without minimal body",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			opts:       minimal,
+			wantStatus: http.StatusServiceUnavailable,
+			wantJSON:   true,
+		},
+		{
+			name:       "minimal body with custom status code",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			opts:       []HandlerOption{WithMinimalBody(ProbeStateNotOK, ""), WithStatusCode(ProbeStateNotOK, http.StatusInternalServerError)},
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   "",
+		},
+		{
+			name:       "verbose takes precedence over minimal body",
+			checks:     []proberesponder.CheckResult{checkCacheOK},
+			opts:       minimal,
+			query:      "?verbose",
+			wantStatus: http.StatusOK,
+			wantBody:   "[+]cache ok\nready check passed\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tt.Run(tc.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			pRes := newResponderWithChecks(tc.checks...)
+
+			w := httptest.NewRecorder()
+			HTTPReady(pRes, tc.opts...)(w, httptest.NewRequest(http.MethodGet, HTTPPathReady+tc.query, nil))
+			asserter.Equal(tc.wantStatus, w.Result().StatusCode)
+			if tc.wantJSON {
+				asserter.Equal(httpHeaderContentTypeJSON, w.Header().Get(httpHeaderContentType))
+				return
+			}
+			asserter.True(strings.HasPrefix(w.Header().Get(httpHeaderContentType), httpHeaderContentTypePlain))
+			asserter.Equal(tc.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestHTTPReady_StatusCodesUnauthorized(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := newResponderWithChecks(checkDBDown)
+
+	w := httptest.NewRecorder()
+	HTTPReady(
+		pRes,
+		WithAuthorizer(BearerToken("ops", "secret")),
+		WithStatusCode(ProbeStateNotOK, http.StatusOK),
+	)(w, httptest.NewRequest(http.MethodGet, HTTPPathReady, nil))
+	asserter.Equal(http.StatusOK, w.Result().StatusCode)
+	asserter.Equal(proberesponder.HealthNotOK.String(), w.Body.String())
+}
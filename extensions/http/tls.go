@@ -0,0 +1,159 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+)
+
+// DefaultCertCheckInterval is the minimum interval between checks of the certificate files
+// for changes
+const DefaultCertCheckInterval = 10 * time.Second
+
+// certReloader loads the certificate from the files, and reloads it whenever either of the
+// files is modified. The files are checked during TLS handshakes, at most once per interval.
+type certReloader struct {
+	certFile      string
+	keyFile       string
+	checkInterval time.Duration
+	logger        loggerOption
+
+	locker    *sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string, checkInterval time.Duration) (*certReloader, error) {
+	cr := &certReloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		checkInterval: checkInterval,
+		locker:        &sync.Mutex{},
+	}
+
+	modTime, err := cr.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cr.load(modTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// latestModTime returns the latest of modification times of the certificate & key files
+func (cr *certReloader) latestModTime() (time.Time, error) {
+	latest := time.Time{}
+	for _, file := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed reading TLS certificate: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (cr *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed loading TLS certificate: %w", err)
+	}
+
+	cr.cert = &cert
+	cr.modTime = modTime
+	cr.checkedAt = time.Now()
+	return nil
+}
+
+// GetCertificate returns the latest certificate, it is set as tls.Config.GetCertificate.
+// If reloading fails, the previous certificate continues to be used.
+func (cr *certReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.locker.Lock()
+	defer cr.locker.Unlock()
+
+	if time.Since(cr.checkedAt) < cr.checkInterval {
+		return cr.cert, nil
+	}
+	cr.checkedAt = time.Now()
+
+	modTime, err := cr.latestModTime()
+	if err == nil && modTime.Equal(cr.modTime) {
+		return cr.cert, nil
+	}
+	if err == nil {
+		err = cr.load(modTime)
+	}
+
+	if err != nil {
+		ctx := context.Background()
+		if hello != nil {
+			ctx = hello.Context()
+		}
+		cr.logger.log().Log(
+			ctx,
+			logging.EventTLSReloadFailure,
+			"failed to reload TLS certificate",
+			slog.String("certFile", cr.certFile),
+			slog.String("keyFile", cr.keyFile),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return cr.cert, nil
+}
+
+// WithTLSConfig serves the probes over TLS, with the config. The config is cloned, and is
+// combined with WithTLSCertificateFiles if both are set.
+func WithTLSConfig(cfg *tls.Config) ServerOption {
+	return func(scfg *serverConfig) {
+		if cfg != nil {
+			scfg.tlsConfig = cfg.Clone()
+		}
+	}
+}
+
+// WithTLSCertificateFiles serves the probes over TLS, with the certificate & key loaded from
+// the PEM encoded files. The files are reloaded whenever they're modified (e.g. renewed by
+// cert-manager), the changes are checked at most once every DefaultCertCheckInterval.
+func WithTLSCertificateFiles(certFile, keyFile string) ServerOption {
+	return func(scfg *serverConfig) {
+		scfg.certFile = certFile
+		scfg.keyFile = keyFile
+	}
+}
+
+// tls returns the TLS config of the server, it is nil if TLS is not configured
+func (scfg *serverConfig) tls() (*tls.Config, error) {
+	if scfg.tlsConfig == nil && scfg.certFile == "" {
+		return nil, nil
+	}
+
+	cfg := scfg.tlsConfig
+	if cfg == nil {
+		cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if scfg.certFile != "" {
+		reloader, err := newCertReloader(scfg.certFile, scfg.keyFile, DefaultCertCheckInterval)
+		if err != nil {
+			return nil, err
+		}
+		reloader.logger = newHandlerConfig(scfg.handlerOpts...).logger
+		cfg.GetCertificate = reloader.GetCertificate
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,145 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/naughtygopher/proberesponder/extensions/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a self signed certificate & key for localhost, with the common name, to
+// the files. The modification time of the files is set as modTime.
+func writeCert(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func Test_certReloader(tt *testing.T) {
+	SetLogger(nil)
+	defer SetLogger(logging.New(nil, nil))
+
+	dir := tt.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	now := time.Now()
+
+	tt.Run("missing files", func(t *testing.T) {
+		_, err := newCertReloader(filepath.Join(dir, "missing.crt"), keyFile, 0)
+		assert.Error(t, err)
+	})
+
+	writeCert(tt, certFile, keyFile, "first", now.Add(-time.Minute))
+	cr, err := newCertReloader(certFile, keyFile, 0)
+	require.NoError(tt, err)
+
+	tt.Run("loaded", func(t *testing.T) {
+		cert, err := cr.GetCertificate(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "first", commonName(t, cert))
+	})
+
+	tt.Run("reloaded", func(t *testing.T) {
+		writeCert(t, certFile, keyFile, "second", now)
+		cert, err := cr.GetCertificate(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", commonName(t, cert))
+	})
+
+	tt.Run("invalid files retain the previous certificate", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(certFile, []byte("invalid"), 0o600))
+		require.NoError(t, os.Chtimes(certFile, now.Add(time.Minute), now.Add(time.Minute)))
+		cert, err := cr.GetCertificate(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "second", commonName(t, cert))
+	})
+
+	tt.Run("checked at most once per interval", func(t *testing.T) {
+		writeCert(t, certFile, keyFile, "third", now.Add(2*time.Minute))
+		throttled, err := newCertReloader(certFile, keyFile, time.Hour)
+		require.NoError(t, err)
+
+		writeCert(t, certFile, keyFile, "fourth", now.Add(3*time.Minute))
+		cert, _ := throttled.GetCertificate(nil)
+		assert.Equal(t, "third", commonName(t, cert))
+	})
+}
+
+func TestWithTLSCertificateFiles(tt *testing.T) {
+	dir := tt.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(tt, certFile, keyFile, "probes", time.Now())
+
+	tt.Run("invalid files", func(t *testing.T) {
+		srv := NewServer(proberesponder.New(), "127.0.0.1", 0, WithTLSCertificateFiles(filepath.Join(dir, "missing"), keyFile))
+		assert.Error(t, srv.ListenAndServe())
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(tt, err)
+
+	pRes := proberesponder.New()
+	pRes.SetNotLive(false)
+	srv := NewServer(
+		pRes, "", 0,
+		WithListener(listener),
+		WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13}),
+		WithTLSCertificateFiles(certFile, keyFile),
+	)
+	go func() { _ = srv.ListenAndServe() }()
+	defer srv.Close()
+
+	raw, err := os.ReadFile(certFile)
+	require.NoError(tt, err)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(raw)
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+		Timeout:   time.Second * 5,
+	}
+
+	resp, err := client.Get("https://" + listener.Addr().String() + HTTPPathLive)
+	require.NoError(tt, err)
+	defer resp.Body.Close()
+	assert.Equal(tt, http.StatusOK, resp.StatusCode)
+	assert.Equal(tt, uint16(tls.VersionTLS13), resp.TLS.Version)
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+const (
+	queryParamVerbose = "verbose"
+	queryParamExclude = "exclude"
+)
+
+// excludedChecks returns the IDs of checks excluded using the query parameter "exclude". It
+// can be repeated, or have multiple comma separated IDs, e.g. ?exclude=mydb&exclude=cache,queue
+func excludedChecks(query url.Values) map[string]bool {
+	excluded := map[string]bool{}
+	for _, value := range query[queryParamExclude] {
+		for _, id := range strings.Split(value, ",") {
+			id = strings.TrimSpace(id)
+			if id != "" {
+				excluded[id] = true
+			}
+		}
+	}
+	return excluded
+}
+
+// effectiveNotOK returns the status after excluding the checks. Since the status is set by
+// dependency probes based on all the checks affecting it, the status is considered OK if all
+// the failing checks affecting it are excluded. If the status is NOT OK without any failing
+// checks, or was set explicitly with a reason or held (e.g. shutting down), it is not affected
+// by the exclusions.
+func effectiveNotOK(
+	status proberesponder.Statuskey,
+	snapshot proberesponder.StatusSnapshot,
+	checks []proberesponder.CheckResult,
+	excluded map[string]bool,
+) bool {
+	notOK := snapshot.NotOK
+	if !notOK || len(excluded) == 0 || snapshot.Reason != "" || snapshot.Held {
+		return notOK
+	}
+
+	failing := 0
+	for _, cr := range checks {
+		if !cr.NotOK || !cr.Affects(status) {
+			continue
+		}
+		if !excluded[cr.ID] {
+			return true
+		}
+		failing++
+	}
+
+	return failing == 0
+}
+
+// respondVerbose responds with the result of each check affecting the status, in the format
+// of Kubernetes apiserver's verbose health checks. e.g.
+//
+//	[+]cache ok
+//	[-]mydb failed: connection refused
+//	[+]queue excluded: ok
+//	ready check failed
+func (hcfg *handlerConfig) respondVerbose(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	rep Report,
+	excluded map[string]bool,
+) {
+	buff := bytes.NewBuffer(nil)
+	failingChecks := false
+	for _, cr := range rep.Checks {
+		if !cr.Affects(rep.Status) {
+			continue
+		}
+
+		switch {
+		case excluded[cr.ID]:
+			fmt.Fprintf(buff, "[+]%s excluded: ok\n", cr.ID)
+		case cr.NotOK:
+			failingChecks = true
+			fmt.Fprintf(buff, "[-]%s failed: %s\n", cr.ID, cr.Error)
+		default:
+			fmt.Fprintf(buff, "[+]%s ok\n", cr.ID)
+		}
+	}
+
+	if rep.NotOK && !failingChecks {
+		reason := rep.Probes[rep.Status].Reason
+		if reason == "" {
+			reason = "status is " + proberesponder.HealthNotOK.String()
+		}
+		fmt.Fprintf(buff, "[-]%s failed: %s\n", rep.Status, reason)
+	}
+
+	result := "passed"
+	if rep.NotOK {
+		result = "failed"
+	}
+	fmt.Fprintf(buff, "%s check %s\n", rep.Status, result)
+
+	w.Header().Add(httpHeaderContentType, httpHeaderContentTypePlain)
+	w.WriteHeader(status)
+	hcfg.write(w, r, buff.Bytes())
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+// newResponderWithChecks returns a responder with ready set as NOT OK, as done by depprober
+// when any of the checks affecting ready fails
+func newResponderWithChecks(checks ...proberesponder.CheckResult) *proberesponder.ProbeResponder {
+	pRes := proberesponder.New()
+	pRes.SetNotStarted(false)
+	pRes.SetNotLive(false)
+	readyOK := true
+	for _, cr := range checks {
+		cr.CheckedAt = time.Now()
+		pRes.SetCheckResult(cr)
+		if cr.NotOK && cr.Affects(proberesponder.StatusReady) {
+			readyOK = false
+		}
+	}
+	pRes.SetNotReady(!readyOK)
+	return pRes
+}
+
+var (
+	affectsReady = []proberesponder.Statuskey{proberesponder.StatusReady}
+	checkDBDown  = proberesponder.CheckResult{ID: "mydb", NotOK: true, Error: "connection refused", AffectedStatuses: affectsReady}
+	checkCacheOK = proberesponder.CheckResult{ID: "cache", AffectedStatuses: affectsReady}
+	checkQueueDn = proberesponder.CheckResult{ID: "queue", NotOK: true, Error: "timeout", AffectedStatuses: affectsReady}
+	checkLiveDn  = proberesponder.CheckResult{ID: "disk", NotOK: true, AffectedStatuses: []proberesponder.Statuskey{proberesponder.StatusLive}}
+)
+
+func TestHTTPReady_ExcludeAndVerbose(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []proberesponder.CheckResult
+		setup      func(pRes *proberesponder.ProbeResponder)
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "verbose, all checks OK",
+			checks:     []proberesponder.CheckResult{checkCacheOK, checkLiveDn},
+			query:      "?verbose",
+			wantStatus: http.StatusOK,
+			wantBody:   "[+]cache ok\nready check passed\n",
+		},
+		{
+			name:       "verbose, failing check",
+			checks:     []proberesponder.CheckResult{checkDBDown, checkCacheOK},
+			query:      "?verbose",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "[-]mydb failed: connection refused\n[+]cache ok\nready check failed\n",
+		},
+		{
+			name:       "failing check excluded",
+			checks:     []proberesponder.CheckResult{checkDBDown, checkCacheOK},
+			query:      "?verbose&exclude=mydb",
+			wantStatus: http.StatusOK,
+			wantBody:   "[+]mydb excluded: ok\n[+]cache ok\nready check passed\n",
+		},
+		{
+			name:       "one of many failing checks excluded",
+			checks:     []proberesponder.CheckResult{checkDBDown, checkQueueDn},
+			query:      "?verbose&exclude=mydb",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "[+]mydb excluded: ok\n[-]queue failed: timeout\nready check failed\n",
+		},
+		{
+			name:       "multiple exclusions, repeated & comma separated",
+			checks:     []proberesponder.CheckResult{checkDBDown, checkQueueDn, checkCacheOK},
+			query:      "?verbose&exclude=mydb&exclude=queue,cache",
+			wantStatus: http.StatusOK,
+			wantBody:   "[+]mydb excluded: ok\n[+]queue excluded: ok\n[+]cache excluded: ok\nready check passed\n",
+		},
+		{
+			name:   "status set as NOT OK without failing checks",
+			checks: []proberesponder.CheckResult{checkCacheOK},
+			setup: func(pRes *proberesponder.ProbeResponder) {
+				pRes.SetStatus(proberesponder.StatusReady, true, "draining")
+			},
+			query:      "?verbose&exclude=cache",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "[+]cache excluded: ok\n[-]ready failed: draining\nready check failed\n",
+		},
+		{
+			name:   "status set as NOT OK with a failing check excluded",
+			checks: []proberesponder.CheckResult{checkDBDown},
+			setup: func(pRes *proberesponder.ProbeResponder) {
+				pRes.SetStatus(proberesponder.StatusReady, true, "shutting down")
+			},
+			query:      "?verbose&exclude=mydb",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "[+]mydb excluded: ok\n[-]ready failed: shutting down\nready check failed\n",
+		},
+		{
+			name:   "status set as NOT OK with a failing check excluded, without verbose",
+			checks: []proberesponder.CheckResult{checkDBDown},
+			setup: func(pRes *proberesponder.ProbeResponder) {
+				pRes.SetStatus(proberesponder.StatusReady, true, "shutting down")
+			},
+			query:      "?exclude=mydb",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "exclusion without verbose",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			query:      "?exclude=mydb",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown exclusion",
+			checks:     []proberesponder.CheckResult{checkDBDown},
+			query:      "?exclude=unknown",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asserter := assert.New(t)
+			pRes := newResponderWithChecks(tt.checks...)
+			if tt.setup != nil {
+				tt.setup(pRes)
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, HTTPPathReady+tt.query, nil)
+			HTTPReady(pRes)(w, r)
+
+			asserter.Equal(tt.wantStatus, w.Result().StatusCode)
+			if tt.wantBody != "" {
+				asserter.Equal(tt.wantBody, w.Body.String())
+				asserter.Equal(httpHeaderContentTypePlain, w.Header().Get(httpHeaderContentType))
+			}
+		})
+	}
+}
+
+func TestHTTPLive_Verbose(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := newResponderWithChecks(checkDBDown)
+	pRes.SetNotLive(true)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, HTTPPathLive+"?verbose", nil)
+	HTTPLive(pRes, WithRedactor(DefaultRedactor()))(w, r)
+
+	asserter.Equal(http.StatusServiceUnavailable, w.Result().StatusCode)
+	asserter.Equal("[-]live failed: status is NOT OK\nlive check failed\n", w.Body.String())
+}
@@ -0,0 +1,153 @@
+// Package logging provides structured logging, using log/slog, for proberesponder and its
+// extensions. The level of every type of event logged is configurable.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sync"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+// LevelOff can be set as the level of an event, to disable logging it
+const LevelOff = slog.Level(math.MaxInt)
+
+type Event string
+
+func (ev Event) String() string {
+	return string(ev)
+}
+
+const (
+	// EventStatusOK is when a probe status changes to OK
+	EventStatusOK Event = "status-ok"
+	// EventStatusNotOK is when a probe status changes to NOT OK
+	EventStatusNotOK Event = "status-not-ok"
+	// EventProbeSuccess is when a dependency probe succeeds
+	EventProbeSuccess Event = "probe-success"
+	// EventProbeFailure is when a dependency probe fails
+	EventProbeFailure Event = "probe-failure"
+	// EventWriteFailure is when writing an HTTP response fails
+	EventWriteFailure Event = "write-failure"
+	// EventEncodeFailure is when encoding an HTTP response fails
+	EventEncodeFailure Event = "encode-failure"
+	// EventAdminOverride is when a probe status is set using the admin endpoints
+	EventAdminOverride Event = "admin-override"
+	// EventAdminDenied is when an unauthorized request is made to the admin endpoints
+	EventAdminDenied Event = "admin-denied"
+	// EventTLSReloadFailure is when reloading the TLS certificate of the server fails
+	EventTLSReloadFailure Event = "tls-reload-failure"
+	// EventShutdownPhase is when a phase of the graceful shutdown starts
+	EventShutdownPhase Event = "shutdown-phase"
+	// EventShutdownFailure is when a shutdown hook fails, or does not complete in time
+	EventShutdownFailure Event = "shutdown-failure"
+)
+
+// Levels is the log level of each event type. Events which are not in the map are logged
+// with the respective level in DefaultLevels.
+type Levels map[Event]slog.Level
+
+func DefaultLevels() Levels {
+	return Levels{
+		EventStatusOK:         slog.LevelInfo,
+		EventStatusNotOK:      slog.LevelWarn,
+		EventProbeSuccess:     slog.LevelDebug,
+		EventProbeFailure:     slog.LevelWarn,
+		EventWriteFailure:     slog.LevelError,
+		EventEncodeFailure:    slog.LevelError,
+		EventAdminOverride:    slog.LevelWarn,
+		EventAdminDenied:      slog.LevelWarn,
+		EventTLSReloadFailure: slog.LevelError,
+		EventShutdownPhase:    slog.LevelInfo,
+		EventShutdownFailure:  slog.LevelError,
+	}
+}
+
+// Logger logs the proberesponder events, with the level configured per event type.
+// A nil Logger is valid, and does not log anything.
+type Logger struct {
+	logger *slog.Logger
+	levels Levels
+}
+
+// New returns a Logger which uses logger to log. If logger is nil, slog.Default() is used,
+// as of the time of logging.
+func New(logger *slog.Logger, levels Levels) *Logger {
+	merged := DefaultLevels()
+	for ev, level := range levels {
+		merged[ev] = level
+	}
+
+	return &Logger{
+		logger: logger,
+		levels: merged,
+	}
+}
+
+// Level returns the configured level of the event
+func (lg *Logger) Level(ev Event) slog.Level {
+	if lg == nil {
+		return LevelOff
+	}
+	level, ok := lg.levels[ev]
+	if !ok {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Log logs the message with the level configured for the event. The event type is added
+// as the attribute "event".
+func (lg *Logger) Log(ctx context.Context, ev Event, msg string, attrs ...slog.Attr) {
+	level := lg.Level(ev)
+	if level == LevelOff {
+		return
+	}
+
+	logger := lg.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	attrs = append(attrs, slog.String("event", ev.String()))
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// Listener returns a status change listener which logs the transitions of the statuses.
+// Since the listener is called every time a status is set, only the calls which actually
+// change the status are logged. If next is not nil, it is called for every status change.
+func Listener(lg *Logger, next proberesponder.StatusChangeListener) proberesponder.StatusChangeListener {
+	locker := &sync.Mutex{}
+	last := map[proberesponder.Statuskey]bool{}
+
+	return func(status proberesponder.Statuskey, value bool) {
+		locker.Lock()
+		previous, known := last[status]
+		last[status] = value
+		locker.Unlock()
+
+		if !known || previous != value {
+			ev := EventStatusOK
+			health := proberesponder.HealthOK
+			if value {
+				ev = EventStatusNotOK
+				health = proberesponder.HealthNotOK
+			}
+
+			lg.Log(
+				context.Background(),
+				ev,
+				"probe status changed",
+				slog.String("status", status.String()),
+				slog.String("health", health.String()),
+				slog.Bool("notOK", value),
+			)
+		}
+
+		if next != nil {
+			next(status, value)
+		}
+	}
+}
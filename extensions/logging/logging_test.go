@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBufferedLogger(levels Levels) (*Logger, *bytes.Buffer) {
+	buff := bytes.NewBuffer(nil)
+	handler := slog.NewJSONHandler(buff, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return New(slog.New(handler), levels), buff
+}
+
+func logLines(t *testing.T, buff *bytes.Buffer) []map[string]any {
+	lines := []map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(buff.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestLogger(tt *testing.T) {
+	tt.Run("default levels", func(t *testing.T) {
+		asserter := assert.New(t)
+		lg, buff := newBufferedLogger(nil)
+		lg.Log(context.Background(), EventProbeFailure, "probe failed", slog.String("serviceID", "mydb"))
+
+		lines := logLines(t, buff)
+		asserter.Len(lines, 1)
+		asserter.Equal("WARN", lines[0]["level"])
+		asserter.Equal("mydb", lines[0]["serviceID"])
+		asserter.Equal(EventProbeFailure.String(), lines[0]["event"])
+	})
+
+	tt.Run("custom levels", func(t *testing.T) {
+		asserter := assert.New(t)
+		lg, buff := newBufferedLogger(Levels{
+			EventProbeFailure: slog.LevelError,
+			EventProbeSuccess: LevelOff,
+		})
+		lg.Log(context.Background(), EventProbeFailure, "probe failed")
+		lg.Log(context.Background(), EventProbeSuccess, "probe succeeded")
+
+		lines := logLines(t, buff)
+		asserter.Len(lines, 1)
+		asserter.Equal("ERROR", lines[0]["level"])
+	})
+
+	tt.Run("nil logger", func(t *testing.T) {
+		asserter := assert.New(t)
+		var lg *Logger
+		asserter.Equal(LevelOff, lg.Level(EventStatusOK))
+		lg.Log(context.Background(), EventStatusOK, "no-op")
+	})
+}
+
+func TestListener(t *testing.T) {
+	asserter := assert.New(t)
+	lg, buff := newBufferedLogger(nil)
+	nextCalls := 0
+	pRes := proberesponder.New()
+	pRes.SetListener(Listener(lg, func(status proberesponder.Statuskey, value bool) {
+		nextCalls++
+	}))
+
+	pRes.SetNotReady(false)
+	pRes.SetNotReady(false)
+	pRes.SetNotReady(true)
+
+	lines := logLines(t, buff)
+	asserter.Equal(3, nextCalls)
+	asserter.Len(lines, 2)
+	asserter.Equal("INFO", lines[0]["level"])
+	asserter.Equal("ready", lines[0]["status"])
+	asserter.Equal("OK", lines[0]["health"])
+	asserter.Equal("WARN", lines[1]["level"])
+	asserter.Equal("NOT OK", lines[1]["health"])
+}
@@ -0,0 +1,121 @@
+// Package metadata provides build and runtime metadata of the application, which can be
+// made part of the proberesponder payload. Build metadata is read using runtime/debug.ReadBuildInfo,
+// and is only available for binaries built with module support.
+package metadata
+
+import (
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+)
+
+const (
+	KeyModule    = "meta->module"
+	KeyVersion   = "meta->version"
+	KeyRevision  = "meta->revision"
+	KeyDirty     = "meta->dirty"
+	KeyGoVersion = "meta->go"
+	KeyHostname  = "meta->hostname"
+	KeyPID       = "meta->pid"
+	KeyStartedAt = "meta->started"
+	KeyUptime    = "meta->uptime"
+)
+
+var (
+	// startedAt is when the package was initialized, which is a close approximation of
+	// when the process was started
+	startedAt = time.Now()
+
+	buildInfoOnce sync.Once
+	buildInfo     Info
+)
+
+type Info struct {
+	// Module is the path of the main module
+	Module string
+	// Version is the version of the main module, "(devel)" if built from a checkout
+	Version string
+	// Revision is the VCS revision (e.g. git commit hash) the binary was built from
+	Revision string
+	// Dirty is true if the working tree had uncommitted changes while building
+	Dirty     bool
+	GoVersion string
+	Hostname  string
+	PID       int
+	StartedAt time.Time
+}
+
+// Uptime returns the duration since the application was started
+func (in Info) Uptime() time.Duration {
+	return time.Since(in.StartedAt)
+}
+
+// Map returns the metadata as key value pairs, in the same format as the proberesponder payload.
+// Uptime is computed as of the time Map is called.
+func (in Info) Map() map[string]string {
+	return map[string]string{
+		KeyModule:    in.Module,
+		KeyVersion:   in.Version,
+		KeyRevision:  in.Revision,
+		KeyDirty:     strconv.FormatBool(in.Dirty),
+		KeyGoVersion: in.GoVersion,
+		KeyHostname:  in.Hostname,
+		KeyPID:       strconv.Itoa(in.PID),
+		KeyStartedAt: in.StartedAt.Format(time.RFC3339),
+		KeyUptime:    in.Uptime().Round(time.Second).String(),
+	}
+}
+
+// Read returns the build and runtime metadata of the application. Build info is read
+// only once, and reused for all subsequent calls.
+func Read() Info {
+	buildInfoOnce.Do(func() {
+		buildInfo = Info{
+			GoVersion: runtime.Version(),
+		}
+
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		buildInfo.Module = bi.Main.Path
+		buildInfo.Version = bi.Main.Version
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				buildInfo.Revision = setting.Value
+			case "vcs.modified":
+				buildInfo.Dirty = setting.Value == "true"
+			}
+		}
+	})
+
+	info := buildInfo
+	info.Hostname, _ = os.Hostname()
+	info.PID = os.Getpid()
+	info.StartedAt = startedAt
+
+	return info
+}
+
+// Register adds the metadata to the health response of the ProbeResponder. Uptime is
+// updated every time the health response is read.
+func Register(pres *proberesponder.ProbeResponder) {
+	info := Read()
+	for key, value := range info.Map() {
+		if key == KeyUptime {
+			continue
+		}
+		pres.AppendHealthResponse(key, value)
+	}
+
+	pres.AppendHealthResponseFunc(KeyUptime, func() string {
+		return info.Uptime().Round(time.Second).String()
+	})
+}
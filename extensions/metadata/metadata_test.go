@@ -0,0 +1,59 @@
+package metadata
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/naughtygopher/proberesponder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	asserter := assert.New(t)
+	info := Read()
+
+	hostname, _ := os.Hostname()
+	asserter.Equal(runtime.Version(), info.GoVersion)
+	asserter.Equal(hostname, info.Hostname)
+	asserter.Equal(os.Getpid(), info.PID)
+	asserter.False(info.StartedAt.IsZero())
+	asserter.True(info.Uptime() > 0)
+	asserter.Equal(info, Read())
+}
+
+func TestInfo_Map(t *testing.T) {
+	asserter := assert.New(t)
+	info := Info{
+		Module:    "github.com/naughtygopher/app",
+		Version:   "v1.2.3",
+		Revision:  "abc123",
+		Dirty:     true,
+		GoVersion: "go1.23.0",
+		Hostname:  "pod-1",
+		PID:       42,
+		StartedAt: time.Now().Add(-time.Minute),
+	}
+
+	got := info.Map()
+	asserter.Equal("v1.2.3", got[KeyVersion])
+	asserter.Equal("abc123", got[KeyRevision])
+	asserter.Equal("true", got[KeyDirty])
+	asserter.Equal("pod-1", got[KeyHostname])
+	asserter.Equal("42", got[KeyPID])
+	asserter.Equal("1m0s", got[KeyUptime])
+}
+
+func TestRegister(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := proberesponder.New()
+	Register(pRes)
+
+	hresp := pRes.HealthResponse()
+	asserter.Equal(strconv.Itoa(os.Getpid()), hresp[KeyPID])
+	asserter.Equal(runtime.Version(), hresp[KeyGoVersion])
+	asserter.Contains(hresp, KeyUptime)
+	asserter.Contains(hresp, KeyStartedAt)
+}
@@ -0,0 +1,72 @@
+package proberesponder
+
+import (
+	"sync"
+)
+
+// hold is an override of a status as NOT OK, which takes precedence over the value set
+type hold struct {
+	id     uint64
+	reason string
+}
+
+// Hold sets the status as NOT OK with the reason, till the returned func is called to release
+// it. While held, the status stays NOT OK even if it's set otherwise, e.g. by dependency probes
+// using SetNotReady. The value set meanwhile is applied once all the holds of the status are
+// released. If the status is held multiple times, the reason of the latest hold is used. It is
+// meant for overrides like shutting down, or an operator draining the app.
+func (pr *ProbeResponder) Hold(status Statuskey, reason string) (release func()) {
+	if pr == nil || !isStatus(status) {
+		return func() {}
+	}
+
+	pr.locker.Lock()
+	pr.holdID++
+	id := pr.holdID
+	pr.holds[status] = append(pr.holds[status], hold{id: id, reason: reason})
+	pr.applyStatus(status, true, reason)
+	pr.locker.Unlock()
+
+	pr.changed()
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			pr.release(status, id)
+		})
+	}
+}
+
+func (pr *ProbeResponder) release(status Statuskey, id uint64) {
+	pr.locker.Lock()
+	holds := pr.holds[status]
+	for i, h := range holds {
+		if h.id == id {
+			pr.holds[status] = append(holds[:i:i], holds[i+1:]...)
+			break
+		}
+	}
+
+	if remaining := pr.holds[status]; len(remaining) > 0 {
+		pr.applyStatus(status, true, remaining[len(remaining)-1].reason)
+	} else {
+		underlying := pr.underlying[status]
+		pr.applyStatus(status, underlying.NotOK, underlying.Reason)
+	}
+	pr.locker.Unlock()
+
+	pr.changed()
+}
+
+// heldWithoutLock returns true if the status is held
+func (pr *ProbeResponder) heldWithoutLock(status Statuskey) bool {
+	return len(pr.holds[status]) > 0
+}
+
+func isStatus(status Statuskey) bool {
+	switch status {
+	case StatusStartup, StatusReady, StatusLive:
+		return true
+	}
+	return false
+}
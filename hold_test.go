@@ -0,0 +1,110 @@
+package proberesponder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHold(tt *testing.T) {
+	tt.Run("held status is not set otherwise", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.SetNotReady(false)
+
+		release := pRes.Hold(StatusReady, "shutting down")
+		ready := pRes.Statuses()[StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.True(ready.Held)
+		asserter.Equal("shutting down", ready.Reason)
+
+		// e.g. set by dependency probes
+		pRes.SetNotReady(false)
+		pRes.SetStatus(StatusReady, false, "recovered")
+		ready = pRes.Statuses()[StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("shutting down", ready.Reason)
+
+		// the value last set is applied once released
+		release()
+		ready = pRes.Statuses()[StatusReady]
+		asserter.False(ready.NotOK)
+		asserter.False(ready.Held)
+		asserter.Equal("recovered", ready.Reason)
+
+		// releasing again is a no-op
+		pRes.SetStatus(StatusReady, true, "db down")
+		release()
+		asserter.Equal("db down", pRes.Reason(StatusReady))
+	})
+
+	tt.Run("multiple holds", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.SetNotReady(false)
+
+		releaseDrain := pRes.Hold(StatusReady, "draining")
+		releaseShutdown := pRes.Hold(StatusReady, "shutting down")
+		asserter.Equal("shutting down", pRes.Reason(StatusReady))
+
+		releaseShutdown()
+		ready := pRes.Statuses()[StatusReady]
+		asserter.True(ready.NotOK)
+		asserter.Equal("draining", ready.Reason)
+
+		releaseDrain()
+		asserter.False(pRes.Statuses()[StatusReady].NotOK)
+	})
+
+	tt.Run("other statuses are not affected", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.SetNotLive(false)
+
+		release := pRes.Hold(StatusReady, "draining")
+		defer release()
+		pRes.SetNotLive(true)
+		pRes.SetNotLive(false)
+		asserter.False(pRes.Statuses()[StatusLive].NotOK)
+		asserter.False(pRes.Statuses()[StatusLive].Held)
+	})
+
+	tt.Run("history & subscribers", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.SetNotReady(false)
+
+		changes := []Change{}
+		unsubscribe := pRes.Subscribe(func(chs []Change) {
+			changes = append(changes, chs...)
+		})
+		defer unsubscribe()
+
+		release := pRes.Hold(StatusReady, "draining")
+		pRes.SetNotReady(false)
+		release()
+
+		statusChanges := []string{}
+		for _, ch := range changes {
+			if ch.Kind == ChangeStatus {
+				statusChanges = append(statusChanges, ch.To)
+			}
+		}
+		asserter.Equal([]string{"NOT OK (draining)", "OK"}, statusChanges)
+
+		history := pRes.History()
+		asserter.Equal("draining", history[len(history)-2].Reason)
+		asserter.False(history[len(history)-1].NotOK)
+	})
+
+	tt.Run("unknown status & nil", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		release := pRes.Hold(Statuskey("unknown"), "reason")
+		release()
+		asserter.Len(pRes.Statuses(), 3)
+
+		var nilRes *ProbeResponder
+		nilRes.Hold(StatusReady, "reason")()
+	})
+}
@@ -0,0 +1,261 @@
+package proberesponder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// RestoredSuffix is appended to the values in the health response, which were restored
+	// from a previous run and have not been updated since
+	RestoredSuffix = " (restored)"
+	// CorruptSuffix is appended to the path of the persisted file, when it cannot be restored.
+	// It is renamed instead of being overwritten, so that it's available for a post-mortem.
+	CorruptSuffix = ".corrupt"
+
+	// DefaultPersistenceDelay is the time waited after a change before persisting the state,
+	// so that a burst of changes (e.g. all the dependency checks) is written only once
+	DefaultPersistenceDelay = 100 * time.Millisecond
+)
+
+type persister struct {
+	path  string
+	delay time.Duration
+
+	locker *sync.Mutex
+	err    error
+	// restoreErr is retained separately, since err is reset on every successful write
+	restoreErr error
+	// pending is the write scheduled after the delay, nil if there's none
+	pending *time.Timer
+	// dirty is true if the state changed since it was last written
+	dirty bool
+
+	// writer serializes the writes, so that the last write is always of the latest state
+	writer *sync.Mutex
+}
+
+// WithPersistence enables persisting the state (statuses, payload & history) of the
+// ProbeResponder to the file at path. The file is written atomically in the background, after
+// DefaultPersistenceDelay of a change, and is restored by New. Flush writes the pending changes
+// immediately, e.g. before exiting.
+func WithPersistence(path string) Option {
+	return func(pr *ProbeResponder) {
+		delay := DefaultPersistenceDelay
+		if pr.persistence != nil {
+			delay = pr.persistence.delay
+		}
+		pr.persistence = &persister{
+			path:   path,
+			delay:  delay,
+			locker: &sync.Mutex{},
+			writer: &sync.Mutex{},
+		}
+	}
+}
+
+// WithPersistenceDelay sets the time waited after a change before persisting the state,
+// instead of DefaultPersistenceDelay. It must be used along with WithPersistence.
+func WithPersistenceDelay(delay time.Duration) Option {
+	return func(pr *ProbeResponder) {
+		if pr.persistence == nil {
+			pr.persistence = &persister{}
+		}
+		pr.persistence.delay = delay
+	}
+}
+
+// PersistenceError returns the last error encountered while persisting the state, or the
+// error encountered while restoring it, if any.
+func (pr *ProbeResponder) PersistenceError() error {
+	if pr == nil || !pr.persistence.enabled() {
+		return nil
+	}
+	pr.persistence.locker.Lock()
+	defer pr.persistence.locker.Unlock()
+
+	if pr.persistence.err != nil {
+		return pr.persistence.err
+	}
+
+	return pr.persistence.restoreErr
+}
+
+func (ps *persister) enabled() bool {
+	return ps != nil && ps.path != ""
+}
+
+func (pr *ProbeResponder) restore() {
+	if !pr.persistence.enabled() {
+		return
+	}
+
+	state, err := pr.persistence.read()
+	if err != nil {
+		pr.persistence.restoreErr = err
+		return
+	}
+	if state == nil {
+		return
+	}
+
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	// restored keys are registered in sorted order, since the order is not persisted
+	keys := make([]string, 0, len(state.Payload))
+	for key := range state.Payload {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		ps := state.Payload[key]
+		pr.registerKeyWithoutLock(key)
+		pr.msgPayload[key] = ps.Value
+		pr.updatedAt[key] = ps.UpdatedAt
+		pr.stale[key] = true
+	}
+
+	for _, trans := range state.History {
+		trans.Restored = true
+		pr.history = append(pr.history, trans)
+	}
+	if excess := len(pr.history) - pr.historySize; excess > 0 {
+		pr.history = append(pr.history[:0], pr.history[excess:]...)
+	}
+}
+
+// persist schedules writing the state after the delay, unless a write is already pending
+func (pr *ProbeResponder) persist() {
+	if !pr.persistence.enabled() {
+		return
+	}
+
+	pr.persistence.locker.Lock()
+	defer pr.persistence.locker.Unlock()
+
+	pr.persistence.dirty = true
+	if pr.persistence.pending != nil {
+		return
+	}
+	pr.persistence.pending = time.AfterFunc(pr.persistence.delay, pr.writeState)
+}
+
+// Flush writes the pending changes immediately, instead of waiting for the delay. It returns
+// the error of the last write, if any. It should be called before exiting, so that the latest
+// state is persisted.
+func (pr *ProbeResponder) Flush() error {
+	if pr == nil || !pr.persistence.enabled() {
+		return nil
+	}
+
+	pr.writeState()
+
+	pr.persistence.locker.Lock()
+	defer pr.persistence.locker.Unlock()
+
+	return pr.persistence.err
+}
+
+// writeState writes the latest state, if it changed since the last write. The pending write
+// is cleared before taking the snapshot, so that any change made during the write schedules
+// another.
+func (pr *ProbeResponder) writeState() {
+	pr.persistence.writer.Lock()
+	defer pr.persistence.writer.Unlock()
+
+	pr.persistence.locker.Lock()
+	dirty := pr.persistence.dirty
+	pr.persistence.dirty = false
+	if pr.persistence.pending != nil {
+		pr.persistence.pending.Stop()
+		pr.persistence.pending = nil
+	}
+	pr.persistence.locker.Unlock()
+
+	if !dirty {
+		return
+	}
+
+	err := pr.persistence.write(pr.Snapshot())
+
+	pr.persistence.locker.Lock()
+	pr.persistence.err = err
+	pr.persistence.locker.Unlock()
+}
+
+func (ps *persister) read() (*Snapshot, error) {
+	raw, err := os.ReadFile(ps.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed reading persisted state: %w", err)
+	}
+
+	state := &Snapshot{}
+	err = json.Unmarshal(raw, state)
+	if err != nil {
+		return nil, ps.quarantine(fmt.Errorf("failed decoding persisted state: %w", err))
+	}
+
+	if state.Version != SnapshotVersion {
+		return nil, ps.quarantine(fmt.Errorf("unsupported version of persisted state: %d", state.Version))
+	}
+
+	return state, nil
+}
+
+// quarantine renames the file which could not be restored, so that it is not overwritten by
+// the next write
+func (ps *persister) quarantine(err error) error {
+	rerr := os.Rename(ps.path, ps.path+CorruptSuffix)
+	if rerr != nil {
+		return errors.Join(err, fmt.Errorf("failed renaming persisted state: %w", rerr))
+	}
+
+	return fmt.Errorf("%w, renamed to %s", err, ps.path+CorruptSuffix)
+}
+
+// write writes the state to a temporary file in the same directory, and then renames
+// it to the target path, so that the file is never partially written
+func (ps *persister) write(state Snapshot) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed encoding state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(ps.path), filepath.Base(ps.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed creating temporary file: %w", err)
+	}
+	defer func() {
+		// no-op if the rename was successful
+		_ = os.Remove(tmp.Name())
+	}()
+
+	_, err = tmp.Write(raw)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed writing temporary file: %w", err)
+	}
+
+	err = os.Rename(tmp.Name(), ps.path)
+	if err != nil {
+		return fmt.Errorf("failed renaming temporary file: %w", err)
+	}
+
+	return nil
+}
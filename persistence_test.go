@@ -0,0 +1,134 @@
+package proberesponder
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistence(tt *testing.T) {
+	tt.Run("persist and restore", func(t *testing.T) {
+		asserter := assert.New(t)
+		requirer := require.New(t)
+		fpath := filepath.Join(t.TempDir(), "state.json")
+
+		pRes := New(WithPersistence(fpath))
+		pRes.SetNotStarted(false)
+		pRes.SetNotReady(false)
+		pRes.AppendHealthResponse("mydb", "NOT OK: connection refused")
+		requirer.NoError(pRes.Flush())
+		requirer.FileExists(fpath)
+
+		restored := New(WithPersistence(fpath))
+		requirer.NoError(restored.PersistenceError())
+		asserter.True(restored.NotStarted())
+		asserter.True(restored.NotReady())
+		asserter.True(restored.NotLive())
+
+		asserter.True(restored.IsStale("mydb"))
+		asserter.False(restored.IsStale("probe->ready"))
+		asserter.Equal(
+			"NOT OK: connection refused"+RestoredSuffix,
+			restored.HealthResponse()["mydb"],
+		)
+
+		history := restored.History()
+		requirer.NotEmpty(history)
+		restoredCount := 0
+		for _, trans := range history {
+			if trans.Restored {
+				restoredCount++
+			}
+		}
+		asserter.Equal(len(pRes.History()), restoredCount)
+
+		restored.AppendHealthResponse("mydb", "OK")
+		asserter.False(restored.IsStale("mydb"))
+		asserter.Equal("OK", restored.HealthResponse()["mydb"])
+	})
+
+	tt.Run("no temporary files left behind", func(t *testing.T) {
+		asserter := assert.New(t)
+		dir := t.TempDir()
+		pRes := New(WithPersistence(filepath.Join(dir, "state.json")))
+		pRes.AppendHealthResponse("key", "value")
+		asserter.NoError(pRes.Flush())
+
+		entries, err := os.ReadDir(dir)
+		asserter.NoError(err)
+		asserter.Len(entries, 1)
+	})
+
+	tt.Run("corrupt file", func(t *testing.T) {
+		asserter := assert.New(t)
+		fpath := filepath.Join(t.TempDir(), "state.json")
+		asserter.NoError(os.WriteFile(fpath, []byte("{"), 0o600))
+
+		pRes := New(WithPersistence(fpath))
+		asserter.ErrorContains(pRes.PersistenceError(), "renamed to "+fpath+CorruptSuffix)
+		asserter.Len(pRes.HealthResponse(), 3)
+
+		// the corrupt file is retained for a post-mortem, instead of being overwritten
+		asserter.NoError(pRes.Flush())
+		raw, err := os.ReadFile(fpath + CorruptSuffix)
+		asserter.NoError(err)
+		asserter.Equal("{", string(raw))
+		asserter.FileExists(fpath)
+		asserter.Error(pRes.PersistenceError())
+	})
+
+	tt.Run("unwritable path", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New(WithPersistence(filepath.Join(t.TempDir(), "missing", "state.json")))
+		asserter.Error(pRes.Flush())
+		asserter.Error(pRes.PersistenceError())
+	})
+
+	tt.Run("changes are written after the delay", func(t *testing.T) {
+		asserter := assert.New(t)
+		fpath := filepath.Join(t.TempDir(), "state.json")
+
+		pRes := New(WithPersistenceDelay(20*time.Millisecond), WithPersistence(fpath))
+		for i := 0; i < 10; i++ {
+			pRes.AppendHealthResponse("counter", strconv.Itoa(i))
+		}
+		asserter.NoFileExists(fpath)
+
+		asserter.Eventually(func() bool {
+			raw, err := os.ReadFile(fpath)
+			return err == nil && strings.Contains(string(raw), `"9"`)
+		}, time.Second, 5*time.Millisecond)
+		asserter.NoError(pRes.PersistenceError())
+	})
+
+	tt.Run("flush writes pending changes", func(t *testing.T) {
+		asserter := assert.New(t)
+		fpath := filepath.Join(t.TempDir(), "state.json")
+
+		pRes := New(WithPersistence(fpath), WithPersistenceDelay(time.Hour))
+		pRes.AppendHealthResponse("key", "value")
+		asserter.NoFileExists(fpath)
+
+		asserter.NoError(pRes.Flush())
+		restored := New(WithPersistence(fpath))
+		asserter.Equal("value"+RestoredSuffix, restored.HealthResponse()["key"])
+	})
+
+	tt.Run("disabled", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		asserter.NoError(pRes.PersistenceError())
+		asserter.NoError(pRes.Flush())
+
+		var nilRes *ProbeResponder
+		asserter.NoError(nilRes.PersistenceError())
+		asserter.NoError(nilRes.Flush())
+		asserter.False(nilRes.IsStale("key"))
+	})
+}
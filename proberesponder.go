@@ -3,6 +3,7 @@ package proberesponder
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,6 +13,11 @@ func (sk Statuskey) String() string {
 	return string(sk)
 }
 
+// PayloadKey returns the key used for the status in the health response
+func (sk Statuskey) PayloadKey() string {
+	return "probe->" + string(sk)
+}
+
 const (
 	StatusStartup Statuskey = "startup"
 	StatusReady   Statuskey = "ready"
@@ -37,17 +43,76 @@ const (
 	HealthNotOK healthstatus = "NOT OK"
 )
 
+// DefaultHistorySize is the number of status transitions retained by default
+const DefaultHistorySize = 100
+
 type StatusChangeListener func(status Statuskey, value bool)
 
+// Transition is a single change of a probe status, as recorded in the history
+type Transition struct {
+	Status Statuskey `json:"status"`
+	NotOK  bool      `json:"notOK"`
+	Reason string    `json:"reason,omitempty"`
+	At     time.Time `json:"at"`
+	// Restored is true if the transition was recorded by a previous run, and was loaded
+	// from the persisted state
+	Restored bool `json:"restored,omitempty"`
+}
+
+// Option is used to configure the ProbeResponder while initializing with New
+type Option func(pr *ProbeResponder)
+
+// WithHistorySize sets the maximum number of status transitions to be retained.
+// A size less than 1 disables the history.
+func WithHistorySize(size int) Option {
+	return func(pr *ProbeResponder) {
+		if size < 0 {
+			size = 0
+		}
+		pr.historySize = size
+	}
+}
+
 // ProbeStatuses are maintained primarily for K8s probe responses. Though it can be used
 // for any prober.
 type ProbeResponder struct {
-	notReady       bool
-	notLive        bool
-	notStarted     bool
+	// the statuses are read without the lock (e.g. by ReadinessGate on every request), and
+	// written only with the lock
+	notReady       atomic.Bool
+	notLive        atomic.Bool
+	notStarted     atomic.Bool
 	locker         *sync.Mutex
 	msgPayload     map[string]string
 	changeListener StatusChangeListener
+
+	// reasons & since are maintained per status, for the last time the respective status was set
+	reasons map[Statuskey]string
+	since   map[Statuskey]time.Time
+	// updatedAt is the last time each of the keys in the payload was updated
+	updatedAt map[string]time.Time
+	// checks are the latest results of dependency checks, by ID
+	checks map[string]CheckResult
+	// keys are all the keys of the payload, in the order of registration
+	keys []string
+	// dynPayload has the functions which are evaluated to get the respective values, every
+	// time the health response is read
+	dynPayload map[string]func() string
+
+	history     []Transition
+	historySize int
+	// stale has the keys of payload restored from a previous run, which have not been
+	// updated since
+	stale         map[string]bool
+	persistence   *persister
+	subscriptions *subscriptions
+	// version is incremented on every change
+	version uint64
+
+	// holds are the overrides of statuses as NOT OK, in the order they were held
+	holds  map[Statuskey][]hold
+	holdID uint64
+	// underlying is the value of each status as last set, which is applied once released
+	underlying map[Statuskey]StatusSnapshot
 }
 
 func (pr *ProbeResponder) AppendHealthResponse(key, value string) {
@@ -55,13 +120,49 @@ func (pr *ProbeResponder) AppendHealthResponse(key, value string) {
 		return
 	}
 	pr.locker.Lock()
-	defer pr.locker.Unlock()
-
 	pr.appendHealthRespWithoutLock(key, value)
+	pr.locker.Unlock()
+
+	pr.changed()
+}
+
+// AppendHealthResponseFunc sets a key in the health response whose value is computed by
+// calling valFn, every time the health response is read. It is useful for values which
+// keep changing, e.g. uptime. valFn must not call any of the ProbeResponder methods.
+func (pr *ProbeResponder) AppendHealthResponseFunc(key string, valFn func() string) {
+	if pr == nil || valFn == nil {
+		return
+	}
+	pr.locker.Lock()
+	pr.registerKeyWithoutLock(key)
+	delete(pr.msgPayload, key)
+	delete(pr.updatedAt, key)
+	delete(pr.stale, key)
+	pr.dynPayload[key] = valFn
+	pr.locker.Unlock()
+
+	pr.changed()
+}
+
+func (pr *ProbeResponder) registerKeyWithoutLock(key string) {
+	_, static := pr.msgPayload[key]
+	_, dynamic := pr.dynPayload[key]
+	if !static && !dynamic {
+		pr.keys = append(pr.keys, key)
+	}
 }
 
 func (pr *ProbeResponder) appendHealthRespWithoutLock(key, value string) {
+	previous, existed := pr.msgPayload[key]
+	if ch, ok := payloadChange(key, previous, existed, value); ok {
+		pr.recordChangeWithoutLock(ch)
+	}
+
+	pr.registerKeyWithoutLock(key)
 	pr.msgPayload[key] = value
+	pr.updatedAt[key] = time.Now()
+	delete(pr.stale, key)
+	delete(pr.dynPayload, key)
 }
 
 func (pr *ProbeResponder) HealthResponse() map[string]string {
@@ -69,26 +170,160 @@ func (pr *ProbeResponder) HealthResponse() map[string]string {
 		return nil
 	}
 	pr.locker.Lock()
-	defer pr.locker.Unlock()
-
 	copied := map[string]string{}
 	for k, v := range pr.msgPayload {
+		if pr.stale[k] {
+			v += RestoredSuffix
+		}
 		copied[k] = v
 	}
+	dynPayload := pr.dynamicPayloadWithoutLock()
+	pr.locker.Unlock()
+
+	for k, valFn := range dynPayload {
+		copied[k] = valFn()
+	}
 
 	return copied
 }
 
-func (pr *ProbeResponder) onChange(status Statuskey, value bool) {
+// HealthResponseKeys returns all the keys of the health response, in the order they were
+// first added
+func (pr *ProbeResponder) HealthResponseKeys() []string {
+	if pr == nil {
+		return nil
+	}
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	return append([]string(nil), pr.keys...)
+}
+
+// dynamicPayloadWithoutLock returns a copy of the dynamic payload functions, so that they
+// can be evaluated without holding the lock
+func (pr *ProbeResponder) dynamicPayloadWithoutLock() map[string]func() string {
+	dynPayload := make(map[string]func() string, len(pr.dynPayload))
+	for k, valFn := range pr.dynPayload {
+		dynPayload[k] = valFn
+	}
+	return dynPayload
+}
+
+// Statuses returns the current state of all the probe statuses
+func (pr *ProbeResponder) Statuses() map[Statuskey]StatusSnapshot {
+	if pr == nil {
+		return nil
+	}
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	return pr.statusesWithoutLock()
+}
+
+func (pr *ProbeResponder) statusesWithoutLock() map[Statuskey]StatusSnapshot {
+	return map[Statuskey]StatusSnapshot{
+		StatusStartup: pr.statusSnapshot(StatusStartup, pr.notStarted.Load()),
+		StatusReady:   pr.statusSnapshot(StatusReady, pr.notReady.Load()),
+		StatusLive:    pr.statusSnapshot(StatusLive, pr.notLive.Load()),
+	}
+}
+
+// IsStale returns true if the value of the key was restored from the persisted state of
+// a previous run, and has not been updated since
+func (pr *ProbeResponder) IsStale(key string) bool {
+	if pr == nil {
+		return false
+	}
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	return pr.stale[key]
+}
+
+// History returns the status transitions recorded, oldest first
+func (pr *ProbeResponder) History() []Transition {
+	if pr == nil {
+		return nil
+	}
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	return append([]Transition(nil), pr.history...)
+}
+
+func (pr *ProbeResponder) recordTransition(status Statuskey, value bool, reason string, at time.Time) {
+	if pr.historySize == 0 {
+		return
+	}
+
+	pr.history = append(pr.history, Transition{
+		Status: status,
+		NotOK:  value,
+		Reason: reason,
+		At:     at,
+	})
+	if excess := len(pr.history) - pr.historySize; excess > 0 {
+		pr.history = append(pr.history[:0], pr.history[excess:]...)
+	}
+}
+
+// setStatus sets the status, unless it is held, in which case it is applied once released
+func (pr *ProbeResponder) setStatus(status Statuskey, value bool, reason string) {
+	if !isStatus(status) {
+		return
+	}
+
+	pr.underlying[status] = StatusSnapshot{NotOK: value, Reason: reason}
+	if pr.heldWithoutLock(status) {
+		return
+	}
+	pr.applyStatus(status, value, reason)
+}
+
+// applyStatus sets the status as is, irrespective of the holds
+func (pr *ProbeResponder) applyStatus(status Statuskey, value bool, reason string) {
+	var current *atomic.Bool
+	switch status {
+	case StatusReady:
+		current = &pr.notReady
+	case StatusLive:
+		current = &pr.notLive
+	case StatusStartup:
+		current = &pr.notStarted
+	default:
+		return
+	}
+
+	now := time.Now()
+	previous := current.Load()
+	changed := previous != value
+	if ch, ok := statusChange(
+		status,
+		StatusSnapshot{NotOK: previous, Reason: pr.reasons[status]},
+		StatusSnapshot{NotOK: value, Reason: reason},
+	); ok {
+		pr.recordChangeWithoutLock(ch)
+	}
+	current.Store(value)
+	pr.reasons[status] = reason
+	if changed {
+		pr.since[status] = now
+		pr.recordTransition(status, value, reason, now)
+	}
+	pr.onChange(status, value, reason, now)
+}
+
+func (pr *ProbeResponder) onChange(status Statuskey, value bool, reason string, at time.Time) {
 	hs := HealthOK
 	if value {
 		hs = HealthNotOK
 	}
 
-	pr.appendHealthRespWithoutLock(
-		"probe->"+status.String(),
-		fmt.Sprintf("%s: %s", hs, time.Now().Format(time.RFC3339)),
-	)
+	msg := fmt.Sprintf("%s: %s", hs, at.Format(time.RFC3339))
+	if reason != "" {
+		msg += " - " + reason
+	}
+	pr.appendHealthRespWithoutLock(status.PayloadKey(), msg)
 
 	if pr.changeListener == nil {
 		return
@@ -103,10 +338,10 @@ func (pr *ProbeResponder) SetNotReady(b bool) {
 	}
 
 	pr.locker.Lock()
-	defer pr.locker.Unlock()
+	pr.setStatus(StatusReady, b, "")
+	pr.locker.Unlock()
 
-	pr.notReady = b
-	pr.onChange(StatusReady, b)
+	pr.changed()
 }
 
 func (pr *ProbeResponder) SetNotLive(b bool) {
@@ -115,10 +350,10 @@ func (pr *ProbeResponder) SetNotLive(b bool) {
 	}
 
 	pr.locker.Lock()
-	defer pr.locker.Unlock()
+	pr.setStatus(StatusLive, b, "")
+	pr.locker.Unlock()
 
-	pr.notLive = b
-	pr.onChange(StatusLive, b)
+	pr.changed()
 }
 
 func (pr *ProbeResponder) SetNotStarted(b bool) {
@@ -126,11 +361,37 @@ func (pr *ProbeResponder) SetNotStarted(b bool) {
 		return
 	}
 
+	pr.locker.Lock()
+	pr.setStatus(StatusStartup, b, "")
+	pr.locker.Unlock()
+
+	pr.changed()
+}
+
+// SetStatus sets the status as NOT OK if notOK is true, along with the reason. The
+// reason is retained until the status is set again. If the status is held (see Hold), the
+// value is applied only once released.
+func (pr *ProbeResponder) SetStatus(status Statuskey, notOK bool, reason string) {
+	if pr == nil {
+		return
+	}
+
+	pr.locker.Lock()
+	pr.setStatus(status, notOK, reason)
+	pr.locker.Unlock()
+
+	pr.changed()
+}
+
+// Reason returns the reason provided when the status was last set
+func (pr *ProbeResponder) Reason(status Statuskey) string {
+	if pr == nil {
+		return ""
+	}
 	pr.locker.Lock()
 	defer pr.locker.Unlock()
 
-	pr.notStarted = b
-	pr.onChange(StatusStartup, b)
+	return pr.reasons[status]
 }
 
 // SetListener is used to set a callback function which will be invoked every time
@@ -147,26 +408,49 @@ func (pr *ProbeResponder) SetListener(l StatusChangeListener) {
 }
 
 func (pr *ProbeResponder) NotReady() bool {
-	return pr != nil && pr.notReady
+	return pr != nil && pr.notReady.Load()
 }
 
 func (pr *ProbeResponder) NotLive() bool {
-	return pr != nil && pr.notLive
+	return pr != nil && pr.notLive.Load()
 }
 
 func (pr *ProbeResponder) NotStarted() bool {
-	return pr != nil && pr.notStarted
+	return pr != nil && pr.notStarted.Load()
 }
 
-func New() *ProbeResponder {
+// New returns a ProbeResponder with all the statuses set as NOT OK. If persistence is
+// enabled, the payload and history of the previous run are restored. Statuses are never
+// restored though, the app is expected to explicitly set them as OK again.
+func New(opts ...Option) *ProbeResponder {
 	pRes := &ProbeResponder{
-		locker:     &sync.Mutex{},
-		msgPayload: map[string]string{},
+		locker:        &sync.Mutex{},
+		msgPayload:    map[string]string{},
+		reasons:       map[Statuskey]string{},
+		since:         map[Statuskey]time.Time{},
+		updatedAt:     map[string]time.Time{},
+		dynPayload:    map[string]func() string{},
+		checks:        map[string]CheckResult{},
+		historySize:   DefaultHistorySize,
+		stale:         map[string]bool{},
+		subscriptions: newSubscriptions(),
+		holds:         map[Statuskey][]hold{},
+		underlying:    map[Statuskey]StatusSnapshot{},
+	}
+
+	for _, opt := range opts {
+		opt(pRes)
 	}
 
-	pRes.SetNotLive(true)
-	pRes.SetNotReady(true)
-	pRes.SetNotStarted(true)
+	pRes.restore()
+
+	pRes.locker.Lock()
+	pRes.setStatus(StatusLive, true, "")
+	pRes.setStatus(StatusReady, true, "")
+	pRes.setStatus(StatusStartup, true, "")
+	pRes.locker.Unlock()
+
+	pRes.changed()
 
 	return pRes
 }
@@ -243,3 +243,103 @@ func Test_HealthResponses(tt *testing.T) {
 		pRes.AppendHealthResponse("key", "value")
 	})
 }
+
+func TestProbeResponder_History(tt *testing.T) {
+	tt.Run("only changes are recorded", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		asserter.Len(pRes.History(), 3)
+
+		pRes.SetNotReady(true)
+		asserter.Len(pRes.History(), 3)
+
+		pRes.SetNotReady(false)
+		history := pRes.History()
+		asserter.Len(history, 4)
+		asserter.Equal(StatusReady, history[3].Status)
+		asserter.False(history[3].NotOK)
+	})
+
+	tt.Run("bounded size", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New(WithHistorySize(2))
+		for i := 0; i < 5; i++ {
+			pRes.SetNotLive(i%2 == 0)
+		}
+		history := pRes.History()
+		asserter.Len(history, 2)
+		asserter.True(history[1].NotOK)
+	})
+
+	tt.Run("disabled", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New(WithHistorySize(-1))
+		pRes.SetNotLive(false)
+		asserter.Empty(pRes.History())
+
+		var nilRes *ProbeResponder
+		asserter.Nil(nilRes.History())
+	})
+}
+
+func TestProbeResponder_AppendHealthResponseFunc(tt *testing.T) {
+	tt.Run("evaluated on every read", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		counter := 0
+		pRes.AppendHealthResponseFunc("counter", func() string {
+			counter++
+			return fmt.Sprintf("%d", counter)
+		})
+
+		asserter.Equal("1", pRes.HealthResponse()["counter"])
+		asserter.Equal("2", pRes.HealthResponse()["counter"])
+		asserter.Equal("3", pRes.Snapshot().Payload["counter"].Value)
+	})
+
+	tt.Run("replaced by static value", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.AppendHealthResponseFunc("key", func() string { return "dynamic" })
+		pRes.AppendHealthResponse("key", "static")
+		asserter.Equal("static", pRes.HealthResponse()["key"])
+
+		pRes.AppendHealthResponseFunc("key", func() string { return "dynamic" })
+		asserter.Equal("dynamic", pRes.HealthResponse()["key"])
+		asserter.Len(pRes.HealthResponse(), 4)
+	})
+
+	tt.Run("nil function", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.AppendHealthResponseFunc("key", nil)
+		asserter.Len(pRes.HealthResponse(), 3)
+
+		var nilRes *ProbeResponder
+		nilRes.AppendHealthResponseFunc("key", func() string { return "" })
+	})
+}
+
+func TestProbeResponder_HealthResponseKeys(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := New()
+	pRes.AppendHealthResponse("zeta", "OK")
+	pRes.AppendHealthResponseFunc("alpha", func() string { return "OK" })
+	pRes.AppendHealthResponse("zeta", "NOT OK")
+	pRes.AppendHealthResponse("alpha", "OK")
+	pRes.SetNotReady(false)
+
+	asserter.Equal(
+		[]string{
+			StatusLive.PayloadKey(),
+			StatusReady.PayloadKey(),
+			StatusStartup.PayloadKey(),
+			"zeta",
+			"alpha",
+		},
+		pRes.HealthResponseKeys(),
+	)
+
+	var nilRes *ProbeResponder
+	asserter.Nil(nilRes.HealthResponseKeys())
+}
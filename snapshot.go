@@ -0,0 +1,200 @@
+package proberesponder
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the version of the Snapshot structure. It is incremented on every
+// incompatible change of the structure.
+const SnapshotVersion = 1
+
+var (
+	// ensure ProbeResponder implements json.Marshaler
+	_ = json.Marshaler(&ProbeResponder{})
+)
+
+// StatusSnapshot is the state of a single probe status
+type StatusSnapshot struct {
+	NotOK  bool   `json:"notOK"`
+	Reason string `json:"reason,omitempty"`
+	// Since is the time when the status last changed
+	Since time.Time `json:"since"`
+	// Held is true if the status is held as NOT OK, see Hold
+	Held bool `json:"held,omitempty"`
+}
+
+func (ss StatusSnapshot) String() string {
+	hs := HealthOK
+	if ss.NotOK {
+		hs = HealthNotOK
+	}
+	if ss.Reason == "" {
+		return hs.String()
+	}
+
+	return fmt.Sprintf("%s (%s)", hs, ss.Reason)
+}
+
+// PayloadSnapshot is the state of a single key in the health response
+type PayloadSnapshot struct {
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Stale is true if the value was restored from a previous run, and was not updated since
+	Stale bool `json:"stale,omitempty"`
+}
+
+// Snapshot is the full state of a ProbeResponder at a point in time
+type Snapshot struct {
+	Version  int                          `json:"version"`
+	TakenAt  time.Time                    `json:"takenAt"`
+	Statuses map[Statuskey]StatusSnapshot `json:"statuses"`
+	Payload  map[string]PayloadSnapshot   `json:"payload"`
+	// Checks are the latest results of dependency checks, they are not restored from the
+	// persisted state
+	Checks  []CheckResult `json:"checks,omitempty"`
+	History []Transition  `json:"history,omitempty"`
+}
+
+// Snapshot returns a copy of the full state of the ProbeResponder
+func (pr *ProbeResponder) Snapshot() Snapshot {
+	if pr == nil {
+		return Snapshot{Version: SnapshotVersion}
+	}
+	pr.locker.Lock()
+	snap := Snapshot{
+		Version:  SnapshotVersion,
+		TakenAt:  time.Now(),
+		Statuses: pr.statusesWithoutLock(),
+		Payload:  make(map[string]PayloadSnapshot, len(pr.msgPayload)),
+		Checks:   pr.checkResultsWithoutLock(),
+		History:  append([]Transition(nil), pr.history...),
+	}
+
+	for key, value := range pr.msgPayload {
+		snap.Payload[key] = PayloadSnapshot{
+			Value:     value,
+			UpdatedAt: pr.updatedAt[key],
+			Stale:     pr.stale[key],
+		}
+	}
+	dynPayload := pr.dynamicPayloadWithoutLock()
+	pr.locker.Unlock()
+
+	for key, valFn := range dynPayload {
+		snap.Payload[key] = PayloadSnapshot{
+			Value:     valFn(),
+			UpdatedAt: snap.TakenAt,
+		}
+	}
+
+	return snap
+}
+
+func (pr *ProbeResponder) statusSnapshot(status Statuskey, notOK bool) StatusSnapshot {
+	return StatusSnapshot{
+		NotOK:  notOK,
+		Reason: pr.reasons[status],
+		Since:  pr.since[status],
+		Held:   pr.heldWithoutLock(status),
+	}
+}
+
+// MarshalJSON marshals the Snapshot of the ProbeResponder
+func (pr *ProbeResponder) MarshalJSON() ([]byte, error) {
+	if pr == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(pr.Snapshot())
+}
+
+type ChangeKind string
+
+func (ck ChangeKind) String() string {
+	return string(ck)
+}
+
+const (
+	ChangeStatus         ChangeKind = "status"
+	ChangePayloadAdded   ChangeKind = "payload-added"
+	ChangePayloadRemoved ChangeKind = "payload-removed"
+	ChangePayloadUpdated ChangeKind = "payload-updated"
+)
+
+// Change is a single difference between two snapshots. For status changes, the key is
+// the status key.
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	Key  string     `json:"key"`
+	From string     `json:"from,omitempty"`
+	To   string     `json:"to,omitempty"`
+}
+
+func (ch Change) String() string {
+	switch ch.Kind {
+	case ChangePayloadAdded:
+		return fmt.Sprintf("+%s: %s", ch.Key, ch.To)
+	case ChangePayloadRemoved:
+		return fmt.Sprintf("-%s: %s", ch.Key, ch.From)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", ch.Key, ch.From, ch.To)
+	}
+}
+
+// Diff returns the changes from snapshot a to snapshot b. Changes of statuses are listed
+// first, followed by the payload changes, each sorted by key. Timestamps are ignored, and
+// only the values are compared.
+func Diff(a, b Snapshot) []Change {
+	changes := make([]Change, 0)
+
+	statuses := make([]string, 0, len(a.Statuses)+len(b.Statuses))
+	for key := range a.Statuses {
+		statuses = append(statuses, key.String())
+	}
+	for key := range b.Statuses {
+		if _, ok := a.Statuses[key]; !ok {
+			statuses = append(statuses, key.String())
+		}
+	}
+	sort.Strings(statuses)
+
+	for _, key := range statuses {
+		status := Statuskey(key)
+		if ch, ok := statusChange(status, a.Statuses[status], b.Statuses[status]); ok {
+			changes = append(changes, ch)
+		}
+	}
+
+	keys := make([]string, 0, len(a.Payload)+len(b.Payload))
+	for key := range a.Payload {
+		keys = append(keys, key)
+	}
+	for key := range b.Payload {
+		if _, ok := a.Payload[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		from, inA := a.Payload[key]
+		to, inB := b.Payload[key]
+		switch {
+		case !inA:
+			changes = append(changes, Change{Kind: ChangePayloadAdded, Key: key, To: to.Value})
+		case !inB:
+			changes = append(changes, Change{Kind: ChangePayloadRemoved, Key: key, From: from.Value})
+		case from.Value != to.Value:
+			changes = append(changes, Change{
+				Kind: ChangePayloadUpdated,
+				Key:  key,
+				From: from.Value,
+				To:   to.Value,
+			})
+		}
+	}
+
+	return changes
+}
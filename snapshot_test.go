@@ -0,0 +1,151 @@
+package proberesponder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbeResponder_Snapshot(tt *testing.T) {
+	tt.Run("full state", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		pRes.SetNotStarted(false)
+		pRes.SetStatus(StatusReady, true, "warming up caches")
+		pRes.AppendHealthResponse("mydb", "OK")
+
+		snap := pRes.Snapshot()
+		asserter.Equal(SnapshotVersion, snap.Version)
+		asserter.False(snap.Statuses[StatusStartup].NotOK)
+		asserter.True(snap.Statuses[StatusReady].NotOK)
+		asserter.Equal("warming up caches", snap.Statuses[StatusReady].Reason)
+		asserter.False(snap.Statuses[StatusReady].Since.IsZero())
+		asserter.Equal("OK", snap.Payload["mydb"].Value)
+		asserter.False(snap.Payload["mydb"].UpdatedAt.IsZero())
+		asserter.Len(snap.History, 4)
+	})
+
+	tt.Run("marshal JSON", func(t *testing.T) {
+		asserter := assert.New(t)
+		requirer := require.New(t)
+		pRes := New()
+		pRes.AppendHealthResponse("mydb", "OK")
+
+		raw, err := json.Marshal(pRes)
+		requirer.NoError(err)
+
+		snap := Snapshot{}
+		requirer.NoError(json.Unmarshal(raw, &snap))
+		asserter.Equal(SnapshotVersion, snap.Version)
+		asserter.Equal("OK", snap.Payload["mydb"].Value)
+		asserter.True(snap.Statuses[StatusLive].NotOK)
+		asserter.Empty(Diff(pRes.Snapshot(), snap))
+	})
+
+	tt.Run("uninitialized", func(t *testing.T) {
+		asserter := assert.New(t)
+		var pRes *ProbeResponder
+		raw, err := json.Marshal(pRes)
+		asserter.NoError(err)
+		asserter.Equal("null", string(raw))
+		asserter.Equal(SnapshotVersion, pRes.Snapshot().Version)
+		asserter.Equal("", pRes.Reason(StatusReady))
+		pRes.SetStatus(StatusReady, false, "")
+	})
+}
+
+func TestProbeResponder_SetStatus(t *testing.T) {
+	asserter := assert.New(t)
+	pRes := New()
+
+	pRes.SetStatus(StatusLive, true, "deadlock detected")
+	asserter.True(pRes.NotLive())
+	asserter.Equal("deadlock detected", pRes.Reason(StatusLive))
+	asserter.Contains(pRes.HealthResponse()["probe->live"], "deadlock detected")
+
+	pRes.SetNotLive(false)
+	asserter.False(pRes.NotLive())
+	asserter.Equal("", pRes.Reason(StatusLive))
+
+	// unknown statuses are ignored
+	pRes.SetStatus(Statuskey("unknown"), true, "")
+	asserter.Len(pRes.HealthResponse(), 3)
+}
+
+func TestDiff(t *testing.T) {
+	base := func() Snapshot {
+		return Snapshot{
+			Version: SnapshotVersion,
+			Statuses: map[Statuskey]StatusSnapshot{
+				StatusStartup: {NotOK: false},
+				StatusReady:   {NotOK: false},
+				StatusLive:    {NotOK: false},
+			},
+			Payload: map[string]PayloadSnapshot{
+				"cache": {Value: "OK"},
+				"mydb":  {Value: "OK"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(s *Snapshot)
+		want   []Change
+	}{
+		{
+			name:   "identical",
+			modify: func(s *Snapshot) {},
+			want:   []Change{},
+		},
+		{
+			name: "status changed",
+			modify: func(s *Snapshot) {
+				s.Statuses[StatusReady] = StatusSnapshot{NotOK: true, Reason: "draining"}
+			},
+			want: []Change{
+				{Kind: ChangeStatus, Key: "ready", From: "OK", To: "NOT OK (draining)"},
+			},
+		},
+		{
+			name: "payload added, removed & updated",
+			modify: func(s *Snapshot) {
+				delete(s.Payload, "cache")
+				s.Payload["mydb"] = PayloadSnapshot{Value: "NOT OK"}
+				s.Payload["queue"] = PayloadSnapshot{Value: "OK"}
+			},
+			want: []Change{
+				{Kind: ChangePayloadRemoved, Key: "cache", From: "OK"},
+				{Kind: ChangePayloadUpdated, Key: "mydb", From: "OK", To: "NOT OK"},
+				{Kind: ChangePayloadAdded, Key: "queue", To: "OK"},
+			},
+		},
+		{
+			name: "only timestamps changed",
+			modify: func(s *Snapshot) {
+				s.Payload["mydb"] = PayloadSnapshot{Value: "OK", Stale: true}
+			},
+			want: []Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := base()
+			tt.modify(&b)
+			assert.Equal(t, tt.want, Diff(base(), b))
+		})
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	asserter := assert.New(t)
+	asserter.Equal("+mydb: OK", Change{Kind: ChangePayloadAdded, Key: "mydb", To: "OK"}.String())
+	asserter.Equal("-mydb: OK", Change{Kind: ChangePayloadRemoved, Key: "mydb", From: "OK"}.String())
+	asserter.Equal(
+		"ready: OK -> NOT OK",
+		Change{Kind: ChangeStatus, Key: "ready", From: "OK", To: "NOT OK"}.String(),
+	)
+}
@@ -0,0 +1,122 @@
+package proberesponder
+
+import (
+	"sync"
+)
+
+// Subscriber is called with the changes of statuses & payload, in the order they were made.
+// It is called synchronously after every change, and must not modify the ProbeResponder.
+type Subscriber func(changes []Change)
+
+type subscription struct {
+	id uint64
+	fn Subscriber
+}
+
+// subscriptions are the subscribers, and the changes pending to be notified to them
+type subscriptions struct {
+	// locker ensures the subscribers are notified in the order of changes
+	locker  *sync.Mutex
+	nextID  uint64
+	subs    []subscription
+	pending []Change
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{locker: &sync.Mutex{}}
+}
+
+// Subscribe registers fn to be notified of all the changes of the statuses & the payload.
+// Values computed using AppendHealthResponseFunc are not notified, since they change every
+// time they are read. The returned func unsubscribes.
+func (pr *ProbeResponder) Subscribe(fn Subscriber) (unsubscribe func()) {
+	if pr == nil || fn == nil {
+		return func() {}
+	}
+
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	pr.subscriptions.nextID++
+	id := pr.subscriptions.nextID
+	pr.subscriptions.subs = append(pr.subscriptions.subs, subscription{id: id, fn: fn})
+
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			pr.locker.Lock()
+			defer pr.locker.Unlock()
+
+			subs := pr.subscriptions.subs
+			for i, sub := range subs {
+				if sub.id == id {
+					pr.subscriptions.subs = append(subs[:i:i], subs[i+1:]...)
+					break
+				}
+			}
+		})
+	}
+}
+
+// recordChangeWithoutLock queues the change to be notified, if there are any subscribers
+func (pr *ProbeResponder) recordChangeWithoutLock(ch Change) {
+	if len(pr.subscriptions.subs) == 0 {
+		return
+	}
+	pr.subscriptions.pending = append(pr.subscriptions.pending, ch)
+}
+
+// changed increments the version, persists the state, and notifies the subscribers of all the
+// pending changes. It must be called after every modification, without holding the lock.
+func (pr *ProbeResponder) changed() {
+	pr.bumpVersion()
+	pr.persist()
+	pr.notify()
+}
+
+func (pr *ProbeResponder) notify() {
+	pr.subscriptions.locker.Lock()
+	defer pr.subscriptions.locker.Unlock()
+
+	pr.locker.Lock()
+	changes := pr.subscriptions.pending
+	pr.subscriptions.pending = nil
+	subs := append([]subscription(nil), pr.subscriptions.subs...)
+	pr.locker.Unlock()
+
+	if len(changes) == 0 {
+		return
+	}
+
+	for _, sub := range subs {
+		sub.fn(append([]Change(nil), changes...))
+	}
+}
+
+// statusChange returns the change of status, and ok as false if neither the value nor
+// the reason changed
+func statusChange(status Statuskey, from, to StatusSnapshot) (Change, bool) {
+	if from.NotOK == to.NotOK && from.Reason == to.Reason {
+		return Change{}, false
+	}
+
+	return Change{
+		Kind: ChangeStatus,
+		Key:  status.String(),
+		From: from.String(),
+		To:   to.String(),
+	}, true
+}
+
+// payloadChange returns the change of the payload key, and ok as false if the value did
+// not change
+func payloadChange(key, from string, existed bool, to string) (Change, bool) {
+	switch {
+	case !existed:
+		return Change{Kind: ChangePayloadAdded, Key: key, To: to}, true
+	case from != to:
+		return Change{Kind: ChangePayloadUpdated, Key: key, From: from, To: to}, true
+	default:
+		return Change{}, false
+	}
+}
@@ -0,0 +1,121 @@
+package proberesponder
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeResponder_Subscribe(tt *testing.T) {
+	tt.Run("changes", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+
+		received := []Change{}
+		unsubscribe := pRes.Subscribe(func(changes []Change) {
+			received = append(received, changes...)
+		})
+
+		pRes.AppendHealthResponse("mydb", "OK")
+		pRes.AppendHealthResponse("mydb", "OK")
+		pRes.AppendHealthResponse("mydb", "NOT OK")
+		pRes.SetStatus(StatusReady, false, "")
+		pRes.SetStatus(StatusReady, true, "draining")
+		pRes.AppendHealthResponseFunc("uptime", func() string { return "1s" })
+
+		kinds := make([]ChangeKind, 0, len(received))
+		keys := make([]string, 0, len(received))
+		for _, ch := range received {
+			kinds = append(kinds, ch.Kind)
+			keys = append(keys, ch.Key)
+		}
+		asserter.Equal([]ChangeKind{
+			ChangePayloadAdded,
+			ChangePayloadUpdated,
+			ChangeStatus,
+			ChangePayloadUpdated,
+			ChangeStatus,
+			ChangePayloadUpdated,
+		}, kinds)
+		asserter.Equal([]string{
+			"mydb", "mydb",
+			"ready", StatusReady.PayloadKey(),
+			"ready", StatusReady.PayloadKey(),
+		}, keys)
+		asserter.Equal("NOT OK", received[2].From)
+		asserter.Equal("OK", received[2].To)
+		asserter.Equal("NOT OK (draining)", received[4].To)
+
+		unsubscribe()
+		unsubscribe()
+		pRes.AppendHealthResponse("mydb", "OK")
+		asserter.Len(received, 6)
+	})
+
+	tt.Run("unchanged status", func(t *testing.T) {
+		pRes := New()
+		count := 0
+		pRes.Subscribe(func(changes []Change) {
+			for _, ch := range changes {
+				if ch.Kind == ChangeStatus {
+					count++
+				}
+			}
+		})
+		pRes.SetNotLive(true)
+		pRes.SetNotLive(true)
+		assert.Equal(t, 0, count)
+	})
+
+	tt.Run("multiple subscribers", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		first, second := 0, 0
+		unsubscribe := pRes.Subscribe(func(changes []Change) { first += len(changes) })
+		pRes.Subscribe(func(changes []Change) { second += len(changes) })
+
+		pRes.AppendHealthResponse("mydb", "OK")
+		unsubscribe()
+		pRes.AppendHealthResponse("cache", "OK")
+		asserter.Equal(1, first)
+		asserter.Equal(2, second)
+	})
+
+	tt.Run("concurrent changes are ordered", func(t *testing.T) {
+		asserter := assert.New(t)
+		pRes := New()
+		locker := sync.Mutex{}
+		last := map[string]string{}
+		pRes.Subscribe(func(changes []Change) {
+			locker.Lock()
+			defer locker.Unlock()
+			for _, ch := range changes {
+				last[ch.Key] = ch.To
+			}
+		})
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pRes.AppendHealthResponse("mydb", "OK")
+				pRes.AppendHealthResponse("mydb", "NOT OK")
+			}()
+		}
+		wg.Wait()
+
+		pRes.AppendHealthResponse("mydb", "final")
+		locker.Lock()
+		asserter.Equal("final", last["mydb"])
+		locker.Unlock()
+	})
+
+	tt.Run("nil", func(t *testing.T) {
+		var pRes *ProbeResponder
+		unsubscribe := pRes.Subscribe(func([]Change) {})
+		unsubscribe()
+		New().Subscribe(nil)()
+	})
+}
@@ -0,0 +1,26 @@
+package proberesponder
+
+// Version returns the version of the state, which is incremented on every change of the
+// statuses, checks & payload. It can be used to cache anything derived from the state, as long
+// as the version is read before the state. cacheable is false if the health response has
+// values computed on read (AppendHealthResponseFunc), since they change without a new version.
+// e.g. uptime registered by metadata.Register, and the in-flight requests of the HTTP
+// extension's InFlight.
+func (pr *ProbeResponder) Version() (version uint64, cacheable bool) {
+	if pr == nil {
+		return 0, false
+	}
+
+	pr.locker.Lock()
+	defer pr.locker.Unlock()
+
+	return pr.version, len(pr.dynPayload) == 0
+}
+
+// bumpVersion increments the version. It must be called after the state is modified, so
+// that the state read after reading a version is never older than the version.
+func (pr *ProbeResponder) bumpVersion() {
+	pr.locker.Lock()
+	pr.version++
+	pr.locker.Unlock()
+}
@@ -0,0 +1,39 @@
+package proberesponder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	asserter := assert.New(t)
+
+	pRes := New()
+	version, cacheable := pRes.Version()
+	asserter.True(cacheable)
+
+	pRes.SetNotReady(false)
+	next, _ := pRes.Version()
+	asserter.Greater(next, version)
+
+	version = next
+	pRes.AppendHealthResponse("version", "v1.2.3")
+	next, _ = pRes.Version()
+	asserter.Greater(next, version)
+
+	version = next
+	pRes.SetCheckResult(CheckResult{ID: "mydb"})
+	next, cacheable = pRes.Version()
+	asserter.Greater(next, version)
+	asserter.True(cacheable)
+
+	pRes.AppendHealthResponseFunc("uptime", func() string { return "1s" })
+	_, cacheable = pRes.Version()
+	asserter.False(cacheable)
+
+	var nilRes *ProbeResponder
+	version, cacheable = nilRes.Version()
+	asserter.Zero(version)
+	asserter.False(cacheable)
+}